@@ -6,6 +6,8 @@ require (
 	github.com/gorilla/websocket v1.4.2
 	github.com/sirupsen/logrus v1.8.1
 	github.com/spf13/cobra v1.2.1
+	github.com/spf13/pflag v1.0.5
 	go.uber.org/ratelimit v0.2.0
 	golang.org/x/time v0.0.0-20210611083556-38a9dc6acbc6
+	gopkg.in/yaml.v2 v2.4.0
 )
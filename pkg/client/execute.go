@@ -0,0 +1,152 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ginkgoch/stress-test/pkg/client/runner"
+	"github.com/ginkgoch/stress-test/pkg/client/statistics"
+)
+
+// Options are the load and the work of Execute, exactly one of Task, MultiTask, Scenarios and VirtualUser is required
+type Options struct {
+	// Concurrency is the thread number, or the pre-allocated thread number with Rate, default 1
+	Concurrency int
+	// Iterations is the task number of every thread when Duration is 0, default 1
+	Iterations int
+	// Duration keeps the threads running until timeout instead of a fixed task number
+	Duration time.Duration
+	// Limit is the task(s) per second shared by all threads, 0 means unlimited
+	Limit int
+	// Rate is the task(s) arriving per second of the open model
+	Rate float64
+	// Poisson spreads the arrivals of Rate as a poisson process
+	Poisson bool
+	// MaxConcurrency is the thread number the open model is allowed to grow to
+	MaxConcurrency int
+	// Stages change the thread number, or Rate, over time
+	Stages []Stage
+
+	// Name is the category of Task, default "task"
+	Name string
+	// Task runs one task measured as a result of category Name
+	Task func(ctx context.Context) error
+	// MultiTask runs the tasks of one iteration writing their own results, e.g. by runner.RunStep
+	MultiTask func(ctx context.Context, ch chan<- *runner.TaskResult) error
+	// Scenarios run at the same time, see RunScenarios
+	Scenarios []*Scenario
+	// VirtualUser runs the lifecycle of the virtual users, see RunVirtualUsers
+	VirtualUser *VirtualUser
+
+	// Reporters show the run, nothing is printed without reporters, see statistics.TableReporter
+	Reporters []statistics.Reporter
+	// Sinks receive every result
+	Sinks []statistics.ResultSink
+	// AbortWhen is checked every second, the run is stopped once it returns true
+	AbortWhen func(st *statistics.ResultStatistics) bool
+}
+
+// Execute runs the work of opts until it is done or ctx is cancelled, and returns the summary of the results
+func Execute(ctx context.Context, opts Options) (*statistics.Summary, error) {
+	st, err := ExecuteStatistics(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return st.Summary(), nil
+}
+
+// ExecuteStatistics works as Execute, but returns the statistics, e.g. to check the thresholds
+func ExecuteStatistics(ctx context.Context, opts Options) (*statistics.ResultStatistics, error) {
+	works := 0
+	for _, set := range []bool{opts.Task != nil, opts.MultiTask != nil, len(opts.Scenarios) > 0, opts.VirtualUser != nil} {
+		if set {
+			works++
+		}
+	}
+
+	if works != 1 {
+		return nil, fmt.Errorf("exactly one of Task, MultiTask, Scenarios and VirtualUser is required")
+	}
+
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
+	s := opts.client()
+	name := opts.Name
+	if name == "" {
+		name = "task"
+	}
+
+	switch {
+	case opts.Task != nil && s.Rate > 0:
+		return s.RunWithArrivalRate(ctx, name, opts.Task), nil
+	case opts.Task != nil:
+		return s.RunSingleTaskWithRateLimiter(ctx, name, nil, opts.Task), nil
+	case opts.MultiTask != nil:
+		if s.Rate > 0 {
+			return nil, fmt.Errorf("MultiTask does not support the arrival rate")
+		}
+
+		return s.RunMultiTasksWithRateLimiter(ctx, name, nil, opts.MultiTask), nil
+	case len(opts.Scenarios) > 0:
+		return s.RunScenarios(ctx, opts.Scenarios)
+	default:
+		return s.RunVirtualUsers(ctx, opts.VirtualUser)
+	}
+}
+
+// validate returns the error of the negative or the conflicting settings instead of running with them
+func (opts *Options) validate() error {
+	switch {
+	case opts.Concurrency < 0:
+		return fmt.Errorf("concurrency <%d> must not be negative", opts.Concurrency)
+	case opts.Iterations < 0:
+		return fmt.Errorf("iterations <%d> must not be negative", opts.Iterations)
+	case opts.Duration < 0:
+		return fmt.Errorf("duration <%v> must not be negative", opts.Duration)
+	case opts.Limit < 0:
+		return fmt.Errorf("limit <%d> must not be negative", opts.Limit)
+	case opts.Rate < 0:
+		return fmt.Errorf("arrival rate <%v> must not be negative", opts.Rate)
+	case opts.MaxConcurrency < 0:
+		return fmt.Errorf("max concurrency <%d> must not be negative", opts.MaxConcurrency)
+	case opts.Poisson && opts.Rate == 0:
+		return fmt.Errorf("poisson arrivals need an arrival rate")
+	}
+
+	for i := range opts.Stages {
+		if err := validateStage(&opts.Stages[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (opts *Options) client() *StressTestClient {
+	concurrency := opts.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	iterations := opts.Iterations
+	if iterations < 1 {
+		iterations = 1
+	}
+
+	s := NewStressClient(iterations, concurrency, opts.Limit)
+	s.Duration = opts.Duration
+	s.Rate = opts.Rate
+	s.Poisson = opts.Poisson
+	s.MaxConcurrentNum = opts.MaxConcurrency
+	s.Stages = opts.Stages
+	s.Sinks = opts.Sinks
+	s.AbortWhen = opts.AbortWhen
+
+	s.Reporters = []statistics.Reporter{}
+	s.Reporters = append(s.Reporters, opts.Reporters...)
+	return s
+}
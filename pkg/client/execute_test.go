@@ -0,0 +1,99 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ginkgoch/stress-test/pkg/client/runner"
+)
+
+func TestExecute(t *testing.T) {
+	var calls int64
+	task := func(ctx context.Context) error {
+		if atomic.AddInt64(&calls, 1)%4 == 0 {
+			return errors.New("every fourth task fails")
+		}
+
+		return nil
+	}
+
+	summary, err := Execute(context.Background(), Options{Concurrency: 2, Iterations: 4, Name: "login", Task: task})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if totals := summary.Totals; totals.TotalNum != 8 || totals.SuccessNum != 6 || totals.FailureNum != 2 {
+		t.Errorf("total %d, success %d, failure %d, want 8, 6 and 2", totals.TotalNum, totals.SuccessNum, totals.FailureNum)
+	}
+
+	if len(summary.Categories) != 1 || summary.Categories[0].Name != "login" {
+		t.Errorf("categories are %+v, want login", summary.Categories)
+	}
+}
+
+func TestExecuteWithRate(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	st, err := ExecuteStatistics(ctx, Options{Iterations: 5, Rate: 200, MaxConcurrency: 10, Task: noop})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !st.OpenModel || st.SuccessNum != 5 {
+		t.Errorf("open model %v, success %d, want true and 5", st.OpenModel, st.SuccessNum)
+	}
+}
+
+func TestExecuteWithMultiTask(t *testing.T) {
+	multiTask := func(ctx context.Context, ch chan<- *runner.TaskResult) error {
+		for _, step := range []string{"sign-in", "play"} {
+			if err := runner.RunStep(ctx, step, ch, noop); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	summary, err := Execute(context.Background(), Options{Iterations: 3, MultiTask: multiTask})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if summary.Totals.TotalNum != 6 || len(summary.Categories) != 2 {
+		t.Errorf("total %d of %d categories, want 6 of 2", summary.Totals.TotalNum, len(summary.Categories))
+	}
+}
+
+func TestExecuteFails(t *testing.T) {
+	multiTask := func(ctx context.Context, ch chan<- *runner.TaskResult) error { return nil }
+
+	cases := []struct {
+		name string
+		opts Options
+	}{
+		{"no work", Options{}},
+		{"two works", Options{Task: noop, MultiTask: multiTask}},
+		{"negative concurrency", Options{Concurrency: -1, Task: noop}},
+		{"negative iterations", Options{Iterations: -1, Task: noop}},
+		{"negative duration", Options{Duration: -time.Second, Task: noop}},
+		{"negative limit", Options{Limit: -1, Task: noop}},
+		{"negative rate", Options{Rate: -1, Task: noop}},
+		{"negative max concurrency", Options{MaxConcurrency: -1, Task: noop}},
+		{"poisson without rate", Options{Poisson: true, Task: noop}},
+		{"stage without duration", Options{Stages: []Stage{{Duration: 0, Target: 1}}, Task: noop}},
+		{"negative stage", Options{Stages: []Stage{{Duration: time.Second, Target: -1}}, Task: noop}},
+		{"multiple tasks with rate", Options{Rate: 10, MultiTask: multiTask}},
+		{"scenarios with stages", Options{Stages: []Stage{{Duration: time.Second, Target: 1}}, Scenarios: []*Scenario{{Name: "a", TaskFunc: noop}}}},
+	}
+
+	for _, c := range cases {
+		if _, err := Execute(context.Background(), c.opts); err == nil {
+			t.Errorf("%s: Execute should fail", c.name)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package feeder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sync"
+
+	"github.com/ginkgoch/stress-test/pkg/client/runner"
+)
+
+// Record is one row of the test data, the values are strings for csv and json values for jsonl and json
+type Record map[string]interface{}
+
+const (
+	// OrderSequential hands the records to all threads in turn
+	OrderSequential = "sequential"
+	// OrderRandom hands the records in a shuffled order, shuffled again for every cycle
+	OrderRandom = "random"
+	// OrderPartitioned splits the records by thread, thread n gets the records n-1, n-1+partitions, ...
+	OrderPartitioned = "partitioned"
+
+	// PolicyRecycle starts over when the records are exhausted
+	PolicyRecycle = "recycle"
+	// PolicyStop stops the thread by ErrExhausted when the records are exhausted
+	PolicyStop = "stop"
+)
+
+// ErrExhausted wraps runner.ErrStop, a task returning it stops its thread without a result
+var ErrExhausted = fmt.Errorf("test data exhausted: %w", runner.ErrStop)
+
+// Feeder hands the records to the tasks, it is safe for concurrent use
+type Feeder struct {
+	Records []Record
+	Order   string
+	Policy  string
+	// Partitions is the thread number sharing the records in partitioned order
+	Partitions int
+
+	cursor      int
+	permutation []int
+	partitions  map[int]int
+	locker      sync.Mutex
+}
+
+func NewFeeder(records []Record, order string, policy string, partitions int) (*Feeder, error) {
+	if len(records) == 0 {
+		return nil, fmt.Errorf("no record to feed")
+	}
+
+	switch order {
+	case OrderSequential, OrderRandom, OrderPartitioned:
+	default:
+		return nil, fmt.Errorf("order <%s> is not supported, use %s, %s or %s", order, OrderSequential, OrderRandom, OrderPartitioned)
+	}
+
+	switch policy {
+	case PolicyRecycle, PolicyStop:
+	default:
+		return nil, fmt.Errorf("policy <%s> is not supported, use %s or %s", policy, PolicyRecycle, PolicyStop)
+	}
+
+	if partitions < 1 {
+		partitions = 1
+	}
+
+	return &Feeder{
+		Records:    records,
+		Order:      order,
+		Policy:     policy,
+		Partitions: partitions,
+		partitions: make(map[int]int),
+	}, nil
+}
+
+// Load loads the records of filepath and creates the feeder, see LoadRecords
+func Load(filepath string, order string, policy string, partitions int) (*Feeder, error) {
+	records, err := LoadRecords(filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewFeeder(records, order, policy, partitions)
+}
+
+// Next returns the record of the task running with ctx, or ErrExhausted with the stop policy
+func (f *Feeder) Next(ctx context.Context) (Record, error) {
+	f.locker.Lock()
+	defer f.locker.Unlock()
+
+	if f.Order == OrderPartitioned {
+		return f.nextOfPartition(runner.WorkerID(ctx))
+	}
+
+	if f.cursor >= len(f.Records) {
+		if f.Policy == PolicyStop {
+			return nil, ErrExhausted
+		}
+
+		f.cursor = 0
+		f.permutation = nil
+	}
+
+	index := f.cursor
+	if f.Order == OrderRandom {
+		if f.permutation == nil {
+			f.permutation = rand.Perm(len(f.Records))
+		}
+
+		index = f.permutation[f.cursor]
+	}
+
+	f.cursor++
+	return f.Records[index], nil
+}
+
+// nextOfPartition takes the records of the thread in turn, threads beyond the partitions share them
+func (f *Feeder) nextOfPartition(workerID int) (Record, error) {
+	partition := 0
+	if workerID > 0 {
+		partition = (workerID - 1) % f.Partitions
+	}
+
+	round := f.partitions[partition]
+	index := partition + round*f.Partitions
+	if index >= len(f.Records) {
+		if f.Policy == PolicyStop || partition >= len(f.Records) {
+			return nil, ErrExhausted
+		}
+
+		round, index = 0, partition
+	}
+
+	f.partitions[partition] = round + 1
+	return f.Records[index], nil
+}
+
+// String returns the value of key as a string, an empty string when it is missing
+func (r Record) String(key string) string {
+	value, ok := r[key]
+	if !ok || value == nil {
+		return ""
+	}
+
+	if str, ok := value.(string); ok {
+		return str
+	}
+
+	return fmt.Sprint(value)
+}
+
+// Decode decodes the record into v by its json tags, e.g. a struct of the test data
+func (r Record) Decode(v interface{}) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
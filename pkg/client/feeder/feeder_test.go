@@ -0,0 +1,169 @@
+package feeder
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/ginkgoch/stress-test/pkg/client/runner"
+)
+
+func numbered(n int) []Record {
+	var records []Record
+	for i := 0; i < n; i++ {
+		records = append(records, Record{"i": i})
+	}
+
+	return records
+}
+
+func take(t *testing.T, f *Feeder, ctx context.Context, n int) []int {
+	var result []int
+	for i := 0; i < n; i++ {
+		record, err := f.Next(ctx)
+		if err != nil {
+			t.Fatalf("record %d failed - %v", i, err)
+		}
+
+		result = append(result, record["i"].(int))
+	}
+
+	return result
+}
+
+func TestSequential(t *testing.T) {
+	f, err := NewFeeder(numbered(3), OrderSequential, PolicyRecycle, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if actual := take(t, f, context.Background(), 7); !reflect.DeepEqual(actual, []int{0, 1, 2, 0, 1, 2, 0}) {
+		t.Errorf("sequential records %v", actual)
+	}
+}
+
+func TestRandom(t *testing.T) {
+	f, err := NewFeeder(numbered(5), OrderRandom, PolicyRecycle, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for cycle := 0; cycle < 3; cycle++ {
+		actual := take(t, f, context.Background(), 5)
+		sort.Ints(actual)
+		if !reflect.DeepEqual(actual, []int{0, 1, 2, 3, 4}) {
+			t.Errorf("cycle %d takes %v, want every record once", cycle, actual)
+		}
+	}
+}
+
+func TestPartitioned(t *testing.T) {
+	f, err := NewFeeder(numbered(7), OrderPartitioned, PolicyRecycle, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		workerID int
+		expected []int
+	}{
+		{1, []int{0, 3, 6, 0}},
+		{2, []int{1, 4, 1}},
+		{3, []int{2, 5, 2}},
+		// the threads beyond the partitions share them
+		{4, []int{3, 6, 0}},
+	}
+
+	for _, c := range cases {
+		ctx := runner.WithWorker(context.Background(), c.workerID)
+		if actual := take(t, f, ctx, len(c.expected)); !reflect.DeepEqual(actual, c.expected) {
+			t.Errorf("worker %d takes %v, want %v", c.workerID, actual, c.expected)
+		}
+	}
+}
+
+func TestStopPolicy(t *testing.T) {
+	for _, order := range []string{OrderSequential, OrderRandom, OrderPartitioned} {
+		f, err := NewFeeder(numbered(2), order, PolicyStop, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		take(t, f, runner.WithWorker(context.Background(), 1), 2)
+		if _, err = f.Next(context.Background()); !errors.Is(err, ErrExhausted) || !runner.Stopped(err) {
+			t.Errorf("%s order after the records returns %v, want ErrExhausted", order, err)
+		}
+	}
+}
+
+func TestNewFeederInvalid(t *testing.T) {
+	if _, err := NewFeeder(nil, OrderSequential, PolicyRecycle, 1); err == nil {
+		t.Error("no record should fail")
+	}
+
+	if _, err := NewFeeder(numbered(1), "shuffled", PolicyRecycle, 1); err == nil {
+		t.Error("unknown order should fail")
+	}
+
+	if _, err := NewFeeder(numbered(1), OrderSequential, "again", 1); err == nil {
+		t.Error("unknown policy should fail")
+	}
+}
+
+func TestLoadRecords(t *testing.T) {
+	dir, err := ioutil.TempDir("", "feeder")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"users.csv":   "id,name\n1234567,amy\n2,bob\n",
+		"users.jsonl": "{\"id\": 1234567, \"name\": \"amy\"}\n\n{\"id\": 2, \"name\": \"bob\"}\n",
+		"users.json":  `[{"id": 1234567, "name": "amy"}, {"id": 2, "name": "bob"}]`,
+	}
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err = ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		records, err := LoadRecords(path)
+		if err != nil {
+			t.Errorf("LoadRecords(%s) failed - %v", name, err)
+			continue
+		}
+
+		if len(records) != 2 || records[0].String("id") != "1234567" || records[1].String("name") != "bob" || records[0].String("missing") != "" {
+			t.Errorf("LoadRecords(%s) = %v", name, records)
+		}
+
+		var user struct {
+			ID   int    `json:"id"`
+			Name string `json:"name"`
+		}
+
+		if name != "users.csv" {
+			if err = records[0].Decode(&user); err != nil || user.ID != 1234567 || user.Name != "amy" {
+				t.Errorf("Decode of %s = %+v, %v", name, user, err)
+			}
+		}
+	}
+
+	for name, content := range map[string]string{"users.xml": "<users/>", "empty.csv": "id,name\n", "broken.json": "[{"} {
+		path := filepath.Join(dir, name)
+		if err = ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err = LoadRecords(path); err == nil {
+			t.Errorf("LoadRecords(%s) should fail", name)
+		}
+	}
+}
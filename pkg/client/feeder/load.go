@@ -0,0 +1,84 @@
+package feeder
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+)
+
+// LoadRecords reads the records of a csv file with the column names in the first line,
+// a jsonl file of one object per line, or a json file of an object array
+func LoadRecords(filepath string) ([]Record, error) {
+	file, err := os.Open(filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	var records []Record
+	switch ext := path.Ext(filepath); ext {
+	case ".csv":
+		records, err = readCSV(file)
+	case ".jsonl":
+		records, err = readJSONL(file)
+	case ".json":
+		decoder := json.NewDecoder(bufio.NewReader(file))
+		decoder.UseNumber()
+		err = decoder.Decode(&records)
+	default:
+		return nil, fmt.Errorf("data format <%s> is not supported, use .csv, .jsonl or .json", ext)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("read data <%s> failed - %v", filepath, err)
+	}
+
+	if len(records) == 0 {
+		return nil, fmt.Errorf("no record in <%s>", filepath)
+	}
+
+	return records, nil
+}
+
+func readCSV(file *os.File) ([]Record, error) {
+	lines, err := csv.NewReader(bufio.NewReader(file)).ReadAll()
+	if err != nil || len(lines) == 0 {
+		return nil, err
+	}
+
+	var records []Record
+	for _, line := range lines[1:] {
+		record := make(Record)
+		for i, name := range lines[0] {
+			if i < len(line) {
+				record[name] = line[i]
+			}
+		}
+
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+func readJSONL(file *os.File) ([]Record, error) {
+	var records []Record
+
+	decoder := json.NewDecoder(bufio.NewReader(file))
+	// the numbers keep their text, e.g. 1234567 instead of 1.234567e+06 in the templates
+	decoder.UseNumber()
+	for decoder.More() {
+		record := make(Record)
+		if err := decoder.Decode(&record); err != nil {
+			return nil, err
+		}
+
+		records = append(records, record)
+	}
+
+	return records, nil
+}
@@ -0,0 +1,102 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"math"
+)
+
+const (
+	chartWidth   = 860
+	chartHeight  = 260
+	chartPadding = 48
+	chartTicks   = 5
+)
+
+type chartSeries struct {
+	Name   string
+	Color  string
+	Values []float64
+}
+
+// lineChart renders the series over xs as an inline svg, so the report works offline
+func lineChart(xs []float64, series []chartSeries) template.HTML {
+	buffer := new(bytes.Buffer)
+	fmt.Fprintf(buffer, `<svg viewBox="0 0 %d %d" class="chart">`, chartWidth, chartHeight)
+
+	if len(xs) == 0 {
+		fmt.Fprintf(buffer, `<text x="%d" y="%d" class="empty">no data</text></svg>`, chartWidth/2, chartHeight/2)
+		return template.HTML(buffer.String())
+	}
+
+	minX, maxX := xs[0], xs[len(xs)-1]
+	if maxX == minX {
+		maxX = minX + 1
+	}
+
+	var maxY float64
+	for _, s := range series {
+		for _, v := range s.Values {
+			maxY = math.Max(maxY, v)
+		}
+	}
+	maxY = niceCeil(maxY)
+
+	plotWidth := float64(chartWidth - chartPadding*2)
+	plotHeight := float64(chartHeight - chartPadding*2)
+	scaleX := func(x float64) float64 {
+		return chartPadding + (x-minX)/(maxX-minX)*plotWidth
+	}
+	scaleY := func(y float64) float64 {
+		return chartPadding + plotHeight - y/maxY*plotHeight
+	}
+
+	for i := 0; i <= chartTicks; i++ {
+		y := maxY * float64(i) / chartTicks
+		fmt.Fprintf(buffer, `<line x1="%d" y1="%.1f" x2="%d" y2="%.1f" class="grid"/>`, chartPadding, scaleY(y), chartWidth-chartPadding, scaleY(y))
+		fmt.Fprintf(buffer, `<text x="%d" y="%.1f" class="tick" text-anchor="end">%s</text>`, chartPadding-6, scaleY(y)+4, formatTick(y))
+
+		x := minX + (maxX-minX)*float64(i)/chartTicks
+		fmt.Fprintf(buffer, `<text x="%.1f" y="%d" class="tick" text-anchor="middle">%ss</text>`, scaleX(x), chartHeight-chartPadding+18, formatTick(x))
+	}
+
+	for i, s := range series {
+		buffer.WriteString(`<polyline fill="none" stroke-width="1.5" stroke="` + s.Color + `" points="`)
+		for j, v := range s.Values {
+			fmt.Fprintf(buffer, "%.1f,%.1f ", scaleX(xs[j]), scaleY(v))
+		}
+		buffer.WriteString(`"/>`)
+
+		legendX := chartPadding + i*140
+		fmt.Fprintf(buffer, `<rect x="%d" y="14" width="12" height="12" fill="%s"/>`, legendX, s.Color)
+		fmt.Fprintf(buffer, `<text x="%d" y="25" class="legend">%s</text>`, legendX+18, template.HTMLEscapeString(s.Name))
+	}
+
+	buffer.WriteString(`</svg>`)
+	return template.HTML(buffer.String())
+}
+
+// niceCeil rounds v up to 1, 2 or 5 times a power of 10
+func niceCeil(v float64) float64 {
+	if v <= 0 {
+		return 1
+	}
+
+	magnitude := math.Pow(10, math.Floor(math.Log10(v)))
+	for _, step := range []float64{1, 2, 5, 10} {
+		if v <= step*magnitude {
+			return step * magnitude
+		}
+	}
+
+	return 10 * magnitude
+}
+
+func formatTick(v float64) string {
+	if v == math.Trunc(v) {
+		return fmt.Sprintf("%.0f", v)
+	}
+
+	return fmt.Sprintf("%.2f", v)
+}
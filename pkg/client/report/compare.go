@@ -0,0 +1,183 @@
+package report
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/ginkgoch/stress-test/pkg/client/statistics"
+)
+
+// Comparison is the run-over-run difference of two summaries
+type Comparison struct {
+	Rows []*CompareRow
+	// Missing are the categories found in only one of the summaries
+	Missing []string
+}
+
+// CompareRow compares one metric of one category, times are in ms and error rates in percent
+type CompareRow struct {
+	Category string
+	Metric   string
+	Old      float64
+	New      float64
+	// Delta is the change in percent of Old, or in percentage points for the error rate
+	Delta       float64
+	Regression  bool
+	Improvement bool
+}
+
+var comparePercentiles = []float64{50, 90, 95, 99}
+
+// Compare compares the totals and the categories of both summaries.
+// A change worse than tolerance percent, or errorTolerance percentage points of the error rate, is a regression
+func Compare(before *statistics.Summary, after *statistics.Summary, tolerance float64, errorTolerance float64) *Comparison {
+	c := &Comparison{}
+	c.compareMetrics(before.Totals, after.Totals, tolerance, errorTolerance)
+
+	oldCategories := categoriesByName(before)
+	newCategories := categoriesByName(after)
+
+	var names []string
+	for name := range oldCategories {
+		names = append(names, name)
+	}
+	for name := range newCategories {
+		if _, ok := oldCategories[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		oldCategory, newCategory := oldCategories[name], newCategories[name]
+		if oldCategory == nil || newCategory == nil {
+			c.Missing = append(c.Missing, name)
+			continue
+		}
+
+		c.compareMetrics(oldCategory, newCategory, tolerance, errorTolerance)
+	}
+
+	return c
+}
+
+func categoriesByName(summary *statistics.Summary) map[string]*statistics.MetricsSummary {
+	result := make(map[string]*statistics.MetricsSummary)
+	for _, category := range summary.Categories {
+		result[category.Name] = category
+	}
+
+	return result
+}
+
+func (c *Comparison) compareMetrics(old *statistics.MetricsSummary, current *statistics.MetricsSummary, tolerance float64, errorTolerance float64) {
+	c.add(old.Name, "qps", old.Qps, current.Qps, tolerance, true)
+
+	row := &CompareRow{Category: old.Name, Metric: "error_rate", Old: old.ErrorRate, New: current.ErrorRate, Delta: current.ErrorRate - old.ErrorRate}
+	row.Regression = row.Delta > errorTolerance
+	row.Improvement = -row.Delta > errorTolerance
+	c.Rows = append(c.Rows, row)
+
+	c.add(old.Name, "mean", old.MeanTime, current.MeanTime, tolerance, false)
+	for _, q := range comparePercentiles {
+		name := statistics.PercentileName(q)
+		oldValue, oldOk := old.Percentiles[name]
+		newValue, newOk := current.Percentiles[name]
+		if oldOk && newOk {
+			c.add(old.Name, name, oldValue, newValue, tolerance, false)
+		}
+	}
+}
+
+func (c *Comparison) add(category string, metric string, old float64, current float64, tolerance float64, higherIsBetter bool) {
+	row := &CompareRow{Category: category, Metric: metric, Old: old, New: current}
+	if old != 0 {
+		row.Delta = (current - old) * 100 / old
+	} else if current != 0 {
+		row.Delta = math.Inf(1)
+	}
+
+	worse := row.Delta
+	if higherIsBetter {
+		worse = -row.Delta
+	}
+
+	row.Regression = worse > tolerance
+	row.Improvement = -worse > tolerance
+	c.Rows = append(c.Rows, row)
+}
+
+// Regressions returns the rows getting worse than the tolerance
+func (c *Comparison) Regressions() []*CompareRow {
+	var result []*CompareRow
+	for _, row := range c.Rows {
+		if row.Regression {
+			result = append(result, row)
+		}
+	}
+
+	return result
+}
+
+// Print prints the side-by-side table of the comparison with the locale and the plain style of t
+func (c *Comparison) Print(t statistics.TableReporter) {
+	width := 8
+	for _, row := range c.Rows {
+		if len(row.Category) > width {
+			width = len(row.Category)
+		}
+	}
+
+	t.PrintHeader([]statistics.Column{
+		{Title: t.Label("类别", "category"), Width: width + 2, Left: true},
+		{Title: t.Label("指标", "metric"), Width: 12, Left: true},
+		{Title: t.Label("旧值", "old"), Width: 12},
+		{Title: t.Label("新值", "new"), Width: 12},
+		{Title: t.Label("变化", "change"), Width: 12},
+		{Title: t.Label("结果", "result"), Width: 14, Left: true},
+	})
+
+	for i, row := range c.Rows {
+		category := row.Category
+		if i > 0 && c.Rows[i-1].Category == row.Category {
+			category = ""
+		}
+
+		result := ""
+		if row.Regression {
+			result = t.Label("✗ 退化", "✗ regression")
+		} else if row.Improvement {
+			result = t.Label("↑ 提升", "↑ improvement")
+		}
+
+		t.Println(fmt.Sprintf(" %-*s │ %-10s │ %10s │ %10s │ %10s │ %s", width, category, row.Metric, row.format(row.Old), row.format(row.New), row.formatDelta(), result))
+	}
+
+	for _, name := range c.Missing {
+		t.Println(fmt.Sprintf("\ncategory <%s> is found in only one of the summaries, skipped", name))
+	}
+}
+
+func (row *CompareRow) format(value float64) string {
+	switch row.Metric {
+	case "qps":
+		return fmt.Sprintf("%.2f", value)
+	case "error_rate":
+		return fmt.Sprintf("%.2f%%", value)
+	default:
+		return fmt.Sprintf("%.2fms", value)
+	}
+}
+
+func (row *CompareRow) formatDelta() string {
+	if row.Metric == "error_rate" {
+		return fmt.Sprintf("%+.2fpp", row.Delta)
+	}
+
+	if math.IsInf(row.Delta, 0) {
+		return "new"
+	}
+
+	return fmt.Sprintf("%+.2f%%", row.Delta)
+}
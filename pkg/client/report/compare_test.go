@@ -0,0 +1,122 @@
+package report
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/ginkgoch/stress-test/pkg/client/statistics"
+)
+
+func metrics(name string, qps float64, errorRate float64, mean float64, p99 float64) *statistics.MetricsSummary {
+	return &statistics.MetricsSummary{
+		Name:        name,
+		Qps:         qps,
+		ErrorRate:   errorRate,
+		MeanTime:    mean,
+		Percentiles: map[string]float64{"p50": mean, "p99": p99},
+	}
+}
+
+func findRow(c *Comparison, category string, metric string) *CompareRow {
+	for _, row := range c.Rows {
+		if row.Category == category && row.Metric == metric {
+			return row
+		}
+	}
+
+	return nil
+}
+
+func TestCompare(t *testing.T) {
+	before := &statistics.Summary{
+		Totals:     metrics("", 1000, 0.5, 10, 50),
+		Categories: []*statistics.MetricsSummary{metrics("login", 500, 0, 8, 40), metrics("logout", 500, 1, 12, 60)},
+	}
+	after := &statistics.Summary{
+		Totals:     metrics("", 850, 2, 10.5, 40),
+		Categories: []*statistics.MetricsSummary{metrics("login", 500, 0, 0, 40), metrics("play", 10, 0, 1, 1)},
+	}
+
+	c := Compare(before, after, 10, 1)
+
+	cases := []struct {
+		category    string
+		metric      string
+		delta       float64
+		regression  bool
+		improvement bool
+	}{
+		{"", "qps", -15, true, false},
+		{"", "error_rate", 1.5, true, false},
+		{"", "mean", 5, false, false},
+		{"", "p99", -20, false, true},
+		{"login", "qps", 0, false, false},
+		{"login", "mean", -100, false, true},
+	}
+
+	for _, tc := range cases {
+		row := findRow(c, tc.category, tc.metric)
+		if row == nil {
+			t.Errorf("no row of %q %s", tc.category, tc.metric)
+			continue
+		}
+
+		if math.Abs(row.Delta-tc.delta) > 1e-9 || row.Regression != tc.regression || row.Improvement != tc.improvement {
+			t.Errorf("%q %s delta %v regression %v improvement %v, want %v %v %v", tc.category, tc.metric,
+				row.Delta, row.Regression, row.Improvement, tc.delta, tc.regression, tc.improvement)
+		}
+	}
+
+	if len(c.Missing) != 2 || c.Missing[0] != "logout" || c.Missing[1] != "play" {
+		t.Errorf("missing %v, want logout and play", c.Missing)
+	}
+
+	if n := len(c.Regressions()); n != 2 {
+		t.Errorf("%d regression(s), want 2", n)
+	}
+}
+
+func TestCompareFromZero(t *testing.T) {
+	before := &statistics.Summary{Totals: metrics("", 0, 0, 0, 0)}
+	after := &statistics.Summary{Totals: metrics("", 0, 0, 5, 0)}
+
+	row := findRow(Compare(before, after, 10, 1), "", "mean")
+	if !math.IsInf(row.Delta, 1) || !row.Regression {
+		t.Errorf("mean from 0 to 5 delta %v regression %v, want +Inf and a regression", row.Delta, row.Regression)
+	}
+}
+
+func TestComparisonPrint(t *testing.T) {
+	before := &statistics.Summary{Totals: metrics("", 1000, 0, 10, 50)}
+	after := &statistics.Summary{Totals: metrics("", 800, 0, 10, 40)}
+	c := Compare(before, after, 10, 1)
+
+	cases := []struct {
+		table    statistics.TableReporter
+		contains []string
+		excludes []string
+	}{
+		{statistics.TableReporter{Locale: statistics.LocaleEnglish, Plain: true}, []string{"category", "metric", "x regression", "^ improvement", "-+-", " | "}, []string{"类别", "│", "✗"}},
+		{statistics.TableReporter{Locale: statistics.LocaleChinese}, []string{"类别", "✗ 退化", "↑ 提升", "│"}, []string{"regression"}},
+	}
+
+	for _, tc := range cases {
+		out := new(bytes.Buffer)
+		tc.table.Out = out
+		c.Print(tc.table)
+
+		for _, text := range tc.contains {
+			if !strings.Contains(out.String(), text) {
+				t.Errorf("%s locale output does not contain %q:\n%s", tc.table.Locale, text, out)
+			}
+		}
+
+		for _, text := range tc.excludes {
+			if strings.Contains(out.String(), text) {
+				t.Errorf("%s locale output contains %q:\n%s", tc.table.Locale, text, out)
+			}
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package report
+
+import (
+	"html/template"
+	"io"
+	"time"
+
+	"github.com/ginkgoch/stress-test/pkg/client/statistics"
+)
+
+type htmlReport struct {
+	*statistics.Summary
+	Percentiles []string
+	Rows        []*htmlMetricsRow
+	// ScenarioRows are the scenarios of a mixed run
+	ScenarioRows []*htmlMetricsRow
+	Throughput   template.HTML
+	Latency      template.HTML
+	ErrorRate    template.HTML
+}
+
+type htmlMetricsRow struct {
+	Metrics     *statistics.MetricsSummary
+	Percentiles []float64
+}
+
+// WriteHTML writes the summary as a self-contained html page with charts
+func WriteHTML(w io.Writer, summary *statistics.Summary) error {
+	data := &htmlReport{Summary: summary}
+	for _, q := range statistics.SummaryPercentiles {
+		data.Percentiles = append(data.Percentiles, statistics.PercentileName(q))
+	}
+
+	newRow := func(m *statistics.MetricsSummary) *htmlMetricsRow {
+		row := &htmlMetricsRow{Metrics: m}
+		for _, name := range data.Percentiles {
+			row.Percentiles = append(row.Percentiles, m.Percentiles[name])
+		}
+
+		return row
+	}
+
+	for _, m := range append([]*statistics.MetricsSummary{summary.Totals}, summary.Categories...) {
+		data.Rows = append(data.Rows, newRow(m))
+	}
+
+	for _, m := range summary.Scenarios {
+		data.ScenarioRows = append(data.ScenarioRows, newRow(m))
+	}
+
+	var (
+		xs               []float64
+		success, failure []float64
+		p50, p95, p99    []float64
+		errorRate        []float64
+		startSecond      = summary.StartTime.Unix()
+		p50Name, p95Name = statistics.PercentileName(50), statistics.PercentileName(95)
+		p99Name          = statistics.PercentileName(99)
+	)
+
+	for _, point := range summary.Timeline {
+		xs = append(xs, float64(point.Second-startSecond))
+		success = append(success, float64(point.SuccessNum))
+		failure = append(failure, float64(point.FailureNum))
+		p50 = append(p50, point.Percentiles[p50Name])
+		p95 = append(p95, point.Percentiles[p95Name])
+		p99 = append(p99, point.Percentiles[p99Name])
+		errorRate = append(errorRate, point.ErrorRate())
+	}
+
+	data.Throughput = lineChart(xs, []chartSeries{
+		{Name: "success/s", Color: "#2e7d32", Values: success},
+		{Name: "failure/s", Color: "#c62828", Values: failure},
+	})
+	data.Latency = lineChart(xs, []chartSeries{
+		{Name: p50Name + " (ms)", Color: "#1565c0", Values: p50},
+		{Name: p95Name + " (ms)", Color: "#ef6c00", Values: p95},
+		{Name: p99Name + " (ms)", Color: "#6a1b9a", Values: p99},
+	})
+	data.ErrorRate = lineChart(xs, []chartSeries{
+		{Name: "error rate (%)", Color: "#c62828", Values: errorRate},
+	})
+
+	return htmlTemplate.Execute(w, data)
+}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"time": func(t time.Time) string {
+		return t.Format("2006-01-02 15:04:05")
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>stress-test report</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 24px auto; max-width: 960px; color: #212121; }
+h1 { font-size: 22px; }
+h2 { font-size: 17px; margin-top: 32px; border-bottom: 1px solid #e0e0e0; padding-bottom: 4px; }
+table { border-collapse: collapse; width: 100%; font-size: 13px; }
+th, td { border: 1px solid #e0e0e0; padding: 4px 8px; text-align: right; }
+th:first-child, td:first-child { text-align: left; }
+td.text { text-align: left; word-break: break-all; }
+th { background: #f5f5f5; }
+.chart { width: 100%; height: auto; }
+.chart .grid { stroke: #eeeeee; }
+.chart .tick, .chart .legend, .chart .empty { font-size: 11px; fill: #616161; }
+</style>
+</head>
+<body>
+<h1>stress-test report</h1>
+<p>{{time .StartTime}} - {{time .EndTime}}, {{printf "%.2f" .DurationSec}} s</p>
+
+{{with .Config}}
+<h2>Configuration</h2>
+<table>
+<tr><th>command</th><td class="text">{{.Command}} {{range .Args}}{{.}} {{end}}</td></tr>
+<tr><th>target</th><td class="text">{{.Target}}</td></tr>
+{{range $name, $value := .Flags}}<tr><th>{{$name}}</th><td class="text">{{$value}}</td></tr>
+{{end}}
+</table>
+{{end}}
+
+<h2>Categories</h2>
+<table>
+<tr><th>name</th><th>total</th><th>success</th><th>failure</th><th>error rate</th><th>qps</th><th>mean (ms)</th>{{range .Percentiles}}<th>{{.}} (ms)</th>{{end}}<th>max (ms)</th></tr>
+{{range .Rows}}<tr><td>{{.Metrics.Name}}</td><td>{{.Metrics.TotalNum}}</td><td>{{.Metrics.SuccessNum}}</td><td>{{.Metrics.FailureNum}}</td><td>{{printf "%.2f" .Metrics.ErrorRate}}%</td><td>{{printf "%.2f" .Metrics.Qps}}</td><td>{{printf "%.2f" .Metrics.MeanTime}}</td>{{range .Percentiles}}<td>{{printf "%.2f" .}}</td>{{end}}<td>{{printf "%.2f" .Metrics.MaxTime}}</td></tr>
+{{end}}
+</table>
+
+{{if .ScenarioRows}}
+<h2>Scenarios</h2>
+<table>
+<tr><th>name</th><th>total</th><th>success</th><th>failure</th><th>error rate</th><th>qps</th><th>mean (ms)</th>{{range .Percentiles}}<th>{{.}} (ms)</th>{{end}}<th>max (ms)</th></tr>
+{{range .ScenarioRows}}<tr><td>{{.Metrics.Name}}</td><td>{{.Metrics.TotalNum}}</td><td>{{.Metrics.SuccessNum}}</td><td>{{.Metrics.FailureNum}}</td><td>{{printf "%.2f" .Metrics.ErrorRate}}%</td><td>{{printf "%.2f" .Metrics.Qps}}</td><td>{{printf "%.2f" .Metrics.MeanTime}}</td>{{range .Percentiles}}<td>{{printf "%.2f" .}}</td>{{end}}<td>{{printf "%.2f" .Metrics.MaxTime}}</td></tr>
+{{end}}
+</table>
+{{end}}
+
+<h2>Throughput</h2>
+{{.Throughput}}
+
+<h2>Latency percentiles</h2>
+{{.Latency}}
+
+<h2>Error rate</h2>
+{{.ErrorRate}}
+
+{{if .Errors}}
+<h2>Errors</h2>
+<table>
+<tr><th>class</th><th>count</th><th>sample</th></tr>
+{{range .Errors}}<tr><td>{{.Class}}</td><td>{{.Count}}</td><td class="text">{{.Sample}}</td></tr>
+{{end}}
+</table>
+{{end}}
+</body>
+</html>
+`))
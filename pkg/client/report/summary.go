@@ -0,0 +1,164 @@
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/ginkgoch/stress-test/pkg/client/statistics"
+)
+
+// WriteSummary writes the summary to filepath, the format is json, csv or html by the file extension
+func WriteSummary(filepath string, summary *statistics.Summary) error {
+	var write func(w io.Writer, summary *statistics.Summary) error
+
+	switch ext := path.Ext(filepath); ext {
+	case ".json":
+		write = WriteJSON
+	case ".csv":
+		write = WriteCSV
+	case ".html", ".htm":
+		write = WriteHTML
+	default:
+		return fmt.Errorf("summary format <%s> is not supported, use .json, .csv or .html", ext)
+	}
+
+	file, err := os.Create(filepath)
+	if err != nil {
+		return err
+	}
+
+	if err = write(file, summary); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+func WriteJSON(w io.Writer, summary *statistics.Summary) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(summary)
+}
+
+// ReadSummary reads the json summary written by WriteJSON
+func ReadSummary(filepath string) (*statistics.Summary, error) {
+	file, err := os.Open(filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	summary := new(statistics.Summary)
+	if err = json.NewDecoder(file).Decode(summary); err != nil {
+		return nil, err
+	}
+
+	if summary.Totals == nil {
+		return nil, fmt.Errorf("no totals in summary <%s>", filepath)
+	}
+
+	return summary, nil
+}
+
+// WriteCSV writes the summary as rows of scope config, total, category, error and category-error
+func WriteCSV(w io.Writer, summary *statistics.Summary) error {
+	writer := csv.NewWriter(w)
+
+	header := []string{"scope", "name", "total", "success", "failure", "dropped", "late", "error_rate", "qps", "rate", "min_ms", "mean_ms", "max_ms"}
+	for _, q := range statistics.SummaryPercentiles {
+		header = append(header, statistics.PercentileName(q)+"_ms")
+	}
+	header = append(header, "value")
+
+	rows := [][]string{header}
+	valueRow := func(scope string, name string, value string) []string {
+		row := make([]string, len(header))
+		row[0], row[1], row[len(row)-1] = scope, name, value
+		return row
+	}
+
+	if config := summary.Config; config != nil {
+		rows = append(rows, valueRow("config", "command", config.Command))
+		rows = append(rows, valueRow("config", "target", config.Target))
+
+		var names []string
+		for name := range config.Flags {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			rows = append(rows, valueRow("config", "flag."+name, config.Flags[name]))
+		}
+	}
+
+	rows = append(rows, valueRow("config", "start_time", summary.StartTime.Format(time.RFC3339Nano)))
+	rows = append(rows, valueRow("config", "end_time", summary.EndTime.Format(time.RFC3339Nano)))
+	rows = append(rows, valueRow("config", "duration_sec", formatFloat(summary.DurationSec)))
+
+	rows = append(rows, metricsRow("total", summary.Totals))
+	for _, category := range summary.Categories {
+		rows = append(rows, metricsRow("category", category))
+	}
+
+	for _, scenario := range summary.Scenarios {
+		rows = append(rows, metricsRow("scenario", scenario))
+	}
+
+	for _, e := range summary.Errors {
+		row := valueRow("error", e.Class, e.Sample)
+		row[2] = strconv.FormatUint(e.Count, 10)
+		rows = append(rows, row)
+	}
+
+	for _, category := range summary.Categories {
+		for _, e := range category.Errors {
+			row := valueRow("category-error", category.Name+":"+e.Class, e.Sample)
+			row[2] = strconv.FormatUint(e.Count, 10)
+			rows = append(rows, row)
+		}
+	}
+
+	if err := writer.WriteAll(rows); err != nil {
+		return err
+	}
+
+	return writer.Error()
+}
+
+func metricsRow(scope string, m *statistics.MetricsSummary) []string {
+	row := []string{
+		scope,
+		m.Name,
+		strconv.FormatUint(m.TotalNum, 10),
+		strconv.FormatUint(m.SuccessNum, 10),
+		strconv.FormatUint(m.FailureNum, 10),
+		strconv.FormatUint(m.DroppedNum, 10),
+		strconv.FormatUint(m.LateNum, 10),
+		formatFloat(m.ErrorRate),
+		formatFloat(m.Qps),
+		formatFloat(m.Rate),
+		formatFloat(m.MinTime),
+		formatFloat(m.MeanTime),
+		formatFloat(m.MaxTime),
+	}
+
+	for _, q := range statistics.SummaryPercentiles {
+		row = append(row, formatFloat(m.Percentiles[statistics.PercentileName(q)]))
+	}
+
+	return append(row, "")
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', 3, 64)
+}
@@ -0,0 +1,17 @@
+package runner
+
+import "sync/atomic"
+
+var activeTasks int64
+
+// ActiveTasks returns the number of tasks running in this process right now
+func ActiveTasks() int64 {
+	return atomic.LoadInt64(&activeTasks)
+}
+
+func trackActive() func() {
+	atomic.AddInt64(&activeTasks, 1)
+	return func() {
+		atomic.AddInt64(&activeTasks, -1)
+	}
+}
@@ -0,0 +1,105 @@
+package runner
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	ErrorClassTimeout            = "timeout"
+	ErrorClassCanceled           = "canceled"
+	ErrorClassDNS                = "dns"
+	ErrorClassConnectionRefused  = "connection-refused"
+	ErrorClassConnectionReset    = "connection-reset"
+	ErrorClassSocketExhausted    = "socket-exhausted"
+	ErrorClassTLS                = "tls"
+	ErrorClassJSONDecode         = "json-decode"
+	ErrorClassWebsocketClose     = "websocket-close"
+	ErrorClassWebsocketHandshake = "websocket-handshake"
+	ErrorClassEOF                = "eof"
+	ErrorClassOther              = "other"
+)
+
+// ClassifiedError is implemented by errors that know their own class, e.g. "http-502"
+type ClassifiedError interface {
+	ErrorClass() string
+}
+
+// ClassifyError groups err into a class like http-<status code>, timeout, connection-refused or dns,
+// it returns an empty string when err is nil
+func ClassifyError(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	var classified ClassifiedError
+	if errors.As(err, &classified) {
+		return classified.ErrorClass()
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return ErrorClassTimeout
+	}
+
+	if errors.Is(err, context.Canceled) {
+		return ErrorClassCanceled
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return ErrorClassDNS
+	}
+
+	switch {
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return ErrorClassConnectionRefused
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.EPIPE):
+		return ErrorClassConnectionReset
+	case errors.Is(err, syscall.EADDRNOTAVAIL), errors.Is(err, syscall.EMFILE), errors.Is(err, syscall.ENFILE):
+		return ErrorClassSocketExhausted
+	}
+
+	var (
+		recordErr    tls.RecordHeaderError
+		authorityErr x509.UnknownAuthorityError
+		hostnameErr  x509.HostnameError
+		invalidErr   x509.CertificateInvalidError
+	)
+	if errors.As(err, &recordErr) || errors.As(err, &authorityErr) || errors.As(err, &hostnameErr) || errors.As(err, &invalidErr) ||
+		strings.Contains(err.Error(), "tls: ") {
+		return ErrorClassTLS
+	}
+
+	var (
+		syntaxErr        *json.SyntaxError
+		unmarshalTypeErr *json.UnmarshalTypeError
+	)
+	if errors.As(err, &syntaxErr) || errors.As(err, &unmarshalTypeErr) {
+		return ErrorClassJSONDecode
+	}
+
+	var closeErr *websocket.CloseError
+	if errors.As(err, &closeErr) {
+		return ErrorClassWebsocketClose
+	}
+
+	if errors.Is(err, websocket.ErrBadHandshake) {
+		return ErrorClassWebsocketHandshake
+	}
+
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return ErrorClassEOF
+	}
+
+	return ErrorClassOther
+}
@@ -0,0 +1,68 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+type statusError struct {
+	code int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("status %d", e.code)
+}
+
+func (e *statusError) ErrorClass() string {
+	return fmt.Sprintf("http-%d", e.code)
+}
+
+func syscallErr(errno syscall.Errno) error {
+	return &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", errno)}
+}
+
+func TestClassifyError(t *testing.T) {
+	var v interface{}
+	syntaxErr := json.Unmarshal([]byte("{"), &v)
+	typeErr := json.Unmarshal([]byte(`"text"`), new(int))
+
+	cases := []struct {
+		err   error
+		class string
+	}{
+		{nil, ""},
+		{fmt.Errorf("request failed - %w", &statusError{502}), "http-502"},
+		{context.DeadlineExceeded, ErrorClassTimeout},
+		{&net.DNSError{Err: "i/o timeout", Name: "example.com", IsTimeout: true}, ErrorClassTimeout},
+		{fmt.Errorf("get - %w", context.Canceled), ErrorClassCanceled},
+		{&net.DNSError{Err: "no such host", Name: "example.com"}, ErrorClassDNS},
+		{syscallErr(syscall.ECONNREFUSED), ErrorClassConnectionRefused},
+		{syscallErr(syscall.ECONNRESET), ErrorClassConnectionReset},
+		{syscallErr(syscall.EPIPE), ErrorClassConnectionReset},
+		{syscallErr(syscall.EADDRNOTAVAIL), ErrorClassSocketExhausted},
+		{syscallErr(syscall.EMFILE), ErrorClassSocketExhausted},
+		{errors.New("remote error: tls: bad certificate"), ErrorClassTLS},
+		{syntaxErr, ErrorClassJSONDecode},
+		{fmt.Errorf("decode - %w", typeErr), ErrorClassJSONDecode},
+		{&websocket.CloseError{Code: websocket.CloseGoingAway}, ErrorClassWebsocketClose},
+		{fmt.Errorf("dial - %w", websocket.ErrBadHandshake), ErrorClassWebsocketHandshake},
+		{io.EOF, ErrorClassEOF},
+		{fmt.Errorf("read - %w", io.ErrUnexpectedEOF), ErrorClassEOF},
+		{errors.New("something else"), ErrorClassOther},
+	}
+
+	for _, c := range cases {
+		if class := ClassifyError(c.err); class != c.class {
+			t.Errorf("ClassifyError(%v) = %q, want %q", c.err, class, c.class)
+		}
+	}
+}
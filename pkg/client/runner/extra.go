@@ -0,0 +1,42 @@
+package runner
+
+import (
+	"context"
+	"sync"
+)
+
+type extraKey struct{}
+
+type extra struct {
+	values map[string]string
+	locker sync.Mutex
+}
+
+func withExtra(ctx context.Context) (context.Context, *extra) {
+	e := &extra{}
+	return context.WithValue(ctx, extraKey{}, e), e
+}
+
+// AddExtra adds a protocol detail, e.g. the http status, to the result of the task running with ctx
+func AddExtra(ctx context.Context, key string, value string) {
+	e, ok := ctx.Value(extraKey{}).(*extra)
+	if !ok {
+		return
+	}
+
+	e.locker.Lock()
+	defer e.locker.Unlock()
+
+	if e.values == nil {
+		e.values = make(map[string]string)
+	}
+
+	e.values[key] = value
+}
+
+func (e *extra) Values() map[string]string {
+	e.locker.Lock()
+	defer e.locker.Unlock()
+
+	return e.values
+}
@@ -0,0 +1,58 @@
+package runner
+
+import (
+	"context"
+	"sync"
+)
+
+type retireKey struct{}
+
+type retirement struct {
+	ch   chan struct{}
+	once sync.Once
+}
+
+func (r *retirement) retire() {
+	r.once.Do(func() {
+		close(r.ch)
+	})
+}
+
+// WithRetire returns a ctx to stop the task loops gracefully. Once retire is called,
+// the loops stop starting new tasks but the running tasks are not interrupted as cancelling ctx does.
+// A retired parent ctx retires the returned ctx as well.
+func WithRetire(ctx context.Context) (context.Context, func()) {
+	r := &retirement{ch: make(chan struct{})}
+
+	parent := Retired(ctx)
+	if parent != nil {
+		go func() {
+			select {
+			case <-parent:
+				r.retire()
+			case <-r.ch:
+			case <-ctx.Done():
+			}
+		}()
+	}
+
+	return context.WithValue(ctx, retireKey{}, r), r.retire
+}
+
+// Retired returns a channel that is closed when ctx is retired, it is nil when ctx is not created by WithRetire
+func Retired(ctx context.Context) <-chan struct{} {
+	if r, ok := ctx.Value(retireKey{}).(*retirement); ok {
+		return r.ch
+	}
+
+	return nil
+}
+
+func isRetired(ctx context.Context) bool {
+	select {
+	case <-Retired(ctx):
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,97 @@
+package runner
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// runUntil runs a loop of RunSync with a task of 20ms, stop is called once the loop is running, at the returned time
+func runUntil(ctx context.Context, stop func()) ([]*TaskResult, uint64) {
+	var stopTime uint64
+	results := collect(func(ch chan<- *TaskResult) {
+		wg := new(sync.WaitGroup)
+		wg.Add(1)
+		go RunSync(ctx, "task", 0, nil, ch, wg, func(ctx context.Context) error {
+			timer := time.NewTimer(20 * time.Millisecond)
+			defer timer.Stop()
+
+			select {
+			case <-timer.C:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+
+		time.Sleep(50 * time.Millisecond)
+		stopTime = uint64(time.Now().UnixNano())
+		stop()
+		wg.Wait()
+	})
+
+	return results, stopTime
+}
+
+func TestRetireFinishesTheRunningTask(t *testing.T) {
+	ctx, retire := WithRetire(context.Background())
+	results, retireTime := runUntil(ctx, retire)
+
+	// the task running at the retirement finishes and is reported
+	if len(results) == 0 || results[len(results)-1].EndTime < retireTime {
+		t.Error("the task running at the retirement is not reported")
+	}
+
+	for _, r := range results {
+		if !r.Success {
+			t.Errorf("retired task failed - %s", r.Err)
+		}
+	}
+
+	if ctx.Err() != nil {
+		t.Error("retire cancels ctx")
+	}
+}
+
+func TestCancelInterruptsTheRunningTask(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx, _ = WithRetire(ctx)
+	results, cancelTime := runUntil(ctx, cancel)
+
+	// the interrupted task is not reported, neither as a failure
+	for _, r := range results {
+		if !r.Success || r.EndTime > cancelTime {
+			t.Errorf("interrupted task is reported - %+v", r)
+		}
+	}
+}
+
+func TestRetiredParent(t *testing.T) {
+	parent, retire := WithRetire(context.Background())
+	child, _ := WithRetire(parent)
+
+	if isRetired(child) {
+		t.Fatal("child is retired before its parent")
+	}
+
+	retire()
+	select {
+	case <-Retired(child):
+	case <-time.After(time.Second):
+		t.Fatal("child is not retired with its parent")
+	}
+
+	if Retired(context.Background()) != nil {
+		t.Error("plain ctx has a retirement")
+	}
+
+	// a retired ctx runs no more tasks, even for an unbounded loop
+	if shouldContinue(child, 0, 0) {
+		t.Error("retired ctx continues")
+	}
+
+	if shouldContinue(context.Background(), 0, 0) {
+		t.Error("plain ctx without a deadline runs an unbounded loop")
+	}
+}
@@ -0,0 +1,178 @@
+package runner
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// LateThreshold is the delay after the intended start time when a scheduled task is considered late
+	LateThreshold = 10 * time.Millisecond
+
+	idleInterval = 100 * time.Millisecond
+)
+
+type scheduledJob struct {
+	intendedTime time.Time
+	iteration    int
+}
+
+// RunArrivalRate starts taskFunc at a fixed or poisson arrival rate regardless of how long the tasks take (open model).
+// It starts with preAllocated threads and grows up to maxConcurrent threads when tasks back up,
+// tasks are dropped when all threads are busy. It schedules num tasks, or until ctx is done or retired when num <= 0.
+// The process time is measured from the intended start time to correct coordinated omission.
+func RunArrivalRate(ctx context.Context, name string, num int, rate float64, poisson bool, preAllocated int, maxConcurrent int, ch chan<- *TaskResult, taskFunc func(ctx context.Context) error) {
+	rateAt := func(elapsed time.Duration) float64 {
+		return rate
+	}
+
+	RunArrivalRateWithProfile(ctx, name, num, rateAt, poisson, preAllocated, maxConcurrent, ch, taskFunc)
+}
+
+// RunArrivalRateWithProfile works as RunArrivalRate, but the rate changes with the elapsed time since start.
+// No task is scheduled while the rate is 0.
+func RunArrivalRateWithProfile(ctx context.Context, name string, num int, rateAt func(elapsed time.Duration) float64, poisson bool, preAllocated int, maxConcurrent int, ch chan<- *TaskResult, taskFunc func(ctx context.Context) error) {
+	jobs := make(chan scheduledJob)
+	wg := new(sync.WaitGroup)
+
+	// a task stopped by ErrStop stops the schedule
+	stop := make(chan struct{})
+	stopOnce := new(sync.Once)
+
+	if maxConcurrent < preAllocated {
+		maxConcurrent = preAllocated
+	}
+
+	// idle counts the threads free for the next arrival, a thread is idle from its start, not from its first receive
+	var idle int64
+	threads := 0
+	startThread := func() {
+		threads++
+		atomic.AddInt64(&idle, 1)
+		wg.Add(1)
+		go func(workerCtx context.Context) {
+			defer wg.Done()
+			for job := range jobs {
+				atomic.AddInt64(&idle, -1)
+				r, err := runScheduledTask(withIteration(workerCtx, job.iteration), name, job.intendedTime, taskFunc)
+				if Stopped(err) {
+					stopOnce.Do(func() { close(stop) })
+				} else if r != nil {
+					ch <- r
+				}
+				atomic.AddInt64(&idle, 1)
+			}
+		}(WithWorker(ctx, WorkerOffset(ctx)+threads))
+	}
+
+	for i := 0; i < preAllocated; i++ {
+		startThread()
+	}
+
+	startTime := time.Now()
+	next := startTime
+	// work is the rate integral left until the next arrival, the first task arrives at once
+	work := 0.0
+	for i := 0; shouldContinue(ctx, i, num); {
+		if !sleepUntil(ctx, next, stop) {
+			break
+		}
+
+		rate := rateAt(next.Sub(startTime))
+		if rate <= 0 {
+			next = next.Add(idleInterval)
+			continue
+		}
+
+		if work > 0 {
+			// the rate is recomputed at least every idleInterval until the arrival, e.g. while it ramps up
+			interval := time.Duration(work / rate * float64(time.Second))
+			if interval > idleInterval {
+				work -= rate * idleInterval.Seconds()
+				next = next.Add(idleInterval)
+			} else {
+				work = 0
+				next = next.Add(interval)
+			}
+
+			continue
+		}
+
+		job := scheduledJob{intendedTime: next, iteration: i}
+		switch {
+		case atomic.LoadInt64(&idle) > 0:
+			jobs <- job
+		case threads < maxConcurrent:
+			startThread()
+			jobs <- job
+		default:
+			ch <- &TaskResult{StartTime: uint64(next.UnixNano()), Category: name, Scenario: ScenarioName(ctx), Dropped: true}
+		}
+
+		i++
+		work = arrivalWork(poisson)
+	}
+
+	close(jobs)
+	wg.Wait()
+}
+
+// runScheduledTask returns no result when the task is interrupted by cancelling ctx or stopped by ErrStop
+func runScheduledTask(ctx context.Context, name string, intendedTime time.Time, taskFunc func(ctx context.Context) error) (*TaskResult, error) {
+	taskCtx, extra := withExtra(ctx)
+
+	done := trackActive()
+	startTime := time.Now()
+	err := taskFunc(taskCtx)
+	endTime := time.Now()
+	done()
+
+	if Interrupted(ctx, err) || Stopped(err) {
+		return nil, err
+	}
+
+	r := newTaskResult(ctx, name, intendedTime, endTime, err)
+	r.Late = startTime.Sub(intendedTime) > LateThreshold
+	r.Extra = extra.Values()
+	return r, err
+}
+
+// arrivalWork returns the rate integral between two arrivals, 1 for the fixed interval
+func arrivalWork(poisson bool) float64 {
+	if poisson {
+		return rand.ExpFloat64()
+	}
+
+	return 1
+}
+
+// sleepUntil returns false when ctx is done or retired, or stop is closed before t
+func sleepUntil(ctx context.Context, t time.Time, stop <-chan struct{}) bool {
+	select {
+	case <-stop:
+		return false
+	default:
+	}
+
+	d := time.Until(t)
+	if d <= 0 {
+		return ctx.Err() == nil && !isRetired(ctx)
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	case <-Retired(ctx):
+		return false
+	case <-stop:
+		return false
+	}
+}
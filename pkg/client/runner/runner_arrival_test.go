@@ -0,0 +1,178 @@
+package runner
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// collect runs the executor and returns its results ordered by the intended start time
+func collect(run func(ch chan<- *TaskResult)) []*TaskResult {
+	ch := make(chan *TaskResult)
+	done := make(chan []*TaskResult)
+	go func() {
+		var results []*TaskResult
+		for r := range ch {
+			results = append(results, r)
+		}
+		done <- results
+	}()
+
+	run(ch)
+	close(ch)
+
+	results := <-done
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].StartTime < results[j].StartTime
+	})
+
+	return results
+}
+
+func TestRunArrivalRateDropsWhenThreadsAreBusy(t *testing.T) {
+	results := collect(func(ch chan<- *TaskResult) {
+		// 20 arrivals within 190ms, the 2 threads are busy for 500ms
+		RunArrivalRate(context.Background(), "task", 20, 100, false, 1, 2, ch, func(ctx context.Context) error {
+			time.Sleep(500 * time.Millisecond)
+			return nil
+		})
+	})
+
+	if len(results) != 20 {
+		t.Fatalf("%d result(s), want 20", len(results))
+	}
+
+	dropped := 0
+	for _, r := range results {
+		if r.Dropped {
+			dropped++
+			if r.Category != "task" || r.StartTime == 0 || r.Success {
+				t.Errorf("dropped result %+v", r)
+			}
+		} else if !r.Success || r.ProcessTime < uint64(500*time.Millisecond) {
+			t.Errorf("run result %+v", r)
+		}
+	}
+
+	if dropped != 18 {
+		t.Errorf("%d dropped, want 18", dropped)
+	}
+}
+
+func TestRunArrivalRateMeasuresLateTasksFromTheSchedule(t *testing.T) {
+	lag := new(sync.Once)
+	rateAt := func(elapsed time.Duration) float64 {
+		// the schedule falls 30ms behind from the third arrival on
+		if elapsed >= 20*time.Millisecond {
+			lag.Do(func() { time.Sleep(30 * time.Millisecond) })
+		}
+
+		return 100
+	}
+
+	results := collect(func(ch chan<- *TaskResult) {
+		RunArrivalRateWithProfile(context.Background(), "task", 5, rateAt, false, 2, 5, ch, func(ctx context.Context) error {
+			return nil
+		})
+	})
+
+	if len(results) != 5 {
+		t.Fatalf("%d result(s), want 5", len(results))
+	}
+
+	startTime := results[0].StartTime
+	for i, r := range results {
+		intended := time.Duration(r.StartTime - startTime)
+		if late := intended >= 20*time.Millisecond; r.Late != late {
+			t.Errorf("arrival %d at %v is late %v, want %v", i, intended, r.Late, late)
+		}
+
+		if r.Dropped || !r.Success {
+			t.Errorf("arrival %d result %+v", i, r)
+		}
+	}
+
+	// the third arrival waits for the schedule, which is part of its process time
+	if d := time.Duration(results[2].ProcessTime); d < 25*time.Millisecond {
+		t.Errorf("process time of the late arrival is %v, want at least 25ms", d)
+	}
+}
+
+func TestRunArrivalRateStops(t *testing.T) {
+	results := collect(func(ch chan<- *TaskResult) {
+		RunArrivalRate(context.Background(), "task", 0, 1000, false, 1, 1, ch, func(ctx context.Context) error {
+			if Iteration(ctx) == 3 {
+				return ErrStop
+			}
+
+			return nil
+		})
+	})
+
+	// num 0 without a deadline schedules nothing
+	if len(results) != 0 {
+		t.Errorf("%d result(s) without a deadline, want 0", len(results))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	results = collect(func(ch chan<- *TaskResult) {
+		RunArrivalRate(ctx, "task", 0, 1000, false, 1, 1, ch, func(ctx context.Context) error {
+			if Iteration(ctx) == 3 {
+				return ErrStop
+			}
+
+			return nil
+		})
+	})
+
+	if ctx.Err() != nil {
+		t.Error("ErrStop does not stop the schedule")
+	}
+
+	// the arrivals after the stopped one may be scheduled before the stop is seen
+	if len(results) < 3 || len(results) > 5 {
+		t.Errorf("%d result(s), want 3 before the stop", len(results))
+	}
+}
+
+func TestRunArrivalRateWithoutRate(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+
+	results := collect(func(ch chan<- *TaskResult) {
+		RunArrivalRateWithProfile(ctx, "task", 10, func(elapsed time.Duration) float64 { return 0 }, false, 1, 1, ch, func(ctx context.Context) error {
+			return nil
+		})
+	})
+
+	if len(results) != 0 {
+		t.Errorf("%d result(s) at rate 0, want 0", len(results))
+	}
+}
+
+func TestArrivalWork(t *testing.T) {
+	if work := arrivalWork(false); work != 1 {
+		t.Errorf("fixed arrival work is %v, want 1", work)
+	}
+
+	const n = 20000
+	sum := 0.0
+	for i := 0; i < n; i++ {
+		work := arrivalWork(true)
+		if work < 0 {
+			t.Fatalf("poisson arrival work %v is negative", work)
+		}
+
+		sum += work
+	}
+
+	// the gaps of a poisson process are exponential with the mean of one arrival
+	if mean := sum / n; math.Abs(mean-1) > 0.05 {
+		t.Errorf("mean poisson arrival work is %v, want 1", mean)
+	}
+}
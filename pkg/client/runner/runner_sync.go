@@ -1,24 +1,57 @@
 package runner
 
 import (
+	"context"
 	"sync"
 	"time"
+
+	"go.uber.org/ratelimit"
 )
 
-func RunSync(name string, num int, ch chan<- *TaskResult, wg *sync.WaitGroup, taskFunc func() error) {
+// RunSync runs taskFunc num times in a row, or until ctx is done or retired when num <= 0,
+// which runs no task when ctx has neither a deadline nor a retirement, see WithRetire.
+// The rateLimiter is optional and is taken before every task, share it between workers to limit the global rate.
+func RunSync(ctx context.Context, name string, num int, rateLimiter ratelimit.Limiter, ch chan<- *TaskResult, wg *sync.WaitGroup, taskFunc func(ctx context.Context) error) {
 	defer wg.Done()
 
-	for i := 0; i < num; i++ {
-		r := runSingleTask(name, taskFunc)
-		ch <- r
+	for i := 0; shouldContinue(ctx, i, num); i++ {
+		take(rateLimiter)
+		r, err := runSingleTask(withIteration(ctx, i), name, taskFunc)
+		if Stopped(err) {
+			return
+		}
+
+		if r != nil {
+			ch <- r
+		}
 	}
 }
 
-func runSingleTask(name string, taskFunc func() error) *TaskResult {
+// runSingleTask returns no result when the task is interrupted by cancelling ctx or stopped by ErrStop
+func runSingleTask(ctx context.Context, name string, taskFunc func(ctx context.Context) error) (*TaskResult, error) {
+	done := trackActive()
+	defer done()
+
+	return measureTask(ctx, name, taskFunc)
+}
+
+func measureTask(ctx context.Context, name string, taskFunc func(ctx context.Context) error) (*TaskResult, error) {
+	taskCtx, extra := withExtra(ctx)
+
 	startTime := time.Now()
-	err := taskFunc()
+	err := taskFunc(taskCtx)
 	endTime := time.Now()
 
+	if Interrupted(ctx, err) || Stopped(err) {
+		return nil, err
+	}
+
+	r := newTaskResult(ctx, name, startTime, endTime, err)
+	r.Extra = extra.Values()
+	return r, err
+}
+
+func newTaskResult(ctx context.Context, name string, startTime time.Time, endTime time.Time, err error) *TaskResult {
 	errMsg := ""
 	if err != nil {
 		errMsg = err.Error()
@@ -31,14 +64,62 @@ func runSingleTask(name string, taskFunc func() error) *TaskResult {
 		StartTime:   uint64(startTime.UnixNano()),
 		EndTime:     uint64(endTime.UnixNano()),
 		Category:    name,
+		Scenario:    ScenarioName(ctx),
 		Err:         errMsg,
+		ErrClass:    ClassifyError(err),
 	}
 }
 
-func RunSyncWithMultiTasks(num int, ch chan<- *TaskResult, wg *sync.WaitGroup, taskFunc func(ch chan<- *TaskResult) error) {
+// RunStep runs one step of a multi-task function as a result of category name into ch,
+// the result is dropped when the step is interrupted by cancelling ctx or stopped by ErrStop
+func RunStep(ctx context.Context, name string, ch chan<- *TaskResult, step func(ctx context.Context) error) error {
+	r, err := measureTask(ctx, name, step)
+	if r != nil {
+		ch <- r
+	}
+
+	return err
+}
+
+// RunSyncWithMultiTasks runs taskFunc num times in a row, or until ctx is done or retired when num <= 0 as RunSync.
+// The rateLimiter is optional and is taken before every taskFunc call.
+func RunSyncWithMultiTasks(ctx context.Context, num int, rateLimiter ratelimit.Limiter, ch chan<- *TaskResult, wg *sync.WaitGroup, taskFunc func(ctx context.Context, ch chan<- *TaskResult) error) {
 	defer wg.Done()
 
-	for i := 0; i < num; i++ {
-		taskFunc(ch)
+	for i := 0; shouldContinue(ctx, i, num); i++ {
+		take(rateLimiter)
+
+		done := trackActive()
+		err := taskFunc(withIteration(ctx, i), ch)
+		done()
+
+		if Stopped(err) {
+			return
+		}
+	}
+}
+
+// Interrupted tells whether err is caused by cancelling ctx rather than by the task itself
+func Interrupted(ctx context.Context, err error) bool {
+	return err != nil && ctx.Err() != nil
+}
+
+func shouldContinue(ctx context.Context, i int, num int) bool {
+	if ctx.Err() != nil || isRetired(ctx) {
+		return false
+	}
+
+	if num <= 0 {
+		// without a deadline or a retirement the loop would never end, run no task as a fixed number of 0
+		_, hasDeadline := ctx.Deadline()
+		return hasDeadline || Retired(ctx) != nil
+	}
+
+	return i < num
+}
+
+func take(rateLimiter ratelimit.Limiter) {
+	if rateLimiter != nil {
+		rateLimiter.Take()
 	}
 }
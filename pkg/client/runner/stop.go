@@ -0,0 +1,14 @@
+package runner
+
+import (
+	"errors"
+)
+
+// ErrStop is returned, or wrapped with %w, by a task to stop its thread without a result,
+// e.g. when the test data is exhausted. The arrival rate executor stops scheduling.
+var ErrStop = errors.New("stop")
+
+// Stopped tells whether err asks the thread to stop, see ErrStop
+func Stopped(err error) bool {
+	return errors.Is(err, ErrStop)
+}
@@ -6,12 +6,22 @@ import (
 )
 
 type TaskResult struct {
-	StartTime   uint64
-	EndTime     uint64
-	ProcessTime uint64
-	Success     bool
-	Category    string
-	Err         string
+	StartTime   uint64 `json:"start"`
+	EndTime     uint64 `json:"end"`
+	ProcessTime uint64 `json:"duration"`
+	Success     bool   `json:"success"`
+	Category    string `json:"category"`
+	// Scenario is the scenario of a mixed run the task belongs to, see WithScenario
+	Scenario string `json:"scenario,omitempty"`
+	Err      string `json:"error,omitempty"`
+	// ErrClass groups the failures, see ClassifyError
+	ErrClass string `json:"class,omitempty"`
+	// Late marks a scheduled task that started later than its intended start time
+	Late bool `json:"late,omitempty"`
+	// Dropped marks a scheduled task that never ran because no thread was available
+	Dropped bool `json:"dropped,omitempty"`
+	// Extra keeps the protocol details of the task, e.g. the http status, see AddExtra
+	Extra map[string]string `json:"extra,omitempty"`
 }
 
 type SerialTaskResult struct {
@@ -0,0 +1,56 @@
+package runner
+
+import "context"
+
+type workerKey struct{}
+
+type iterationKey struct{}
+
+type scenarioKey struct{}
+
+type workerOffsetKey struct{}
+
+// WithWorker returns ctx of the thread with id, see WorkerID
+func WithWorker(ctx context.Context, id int) context.Context {
+	return context.WithValue(ctx, workerKey{}, id)
+}
+
+// WorkerID returns the 1-based id of the thread running the task of ctx, 0 when it is unknown
+func WorkerID(ctx context.Context) int {
+	id, _ := ctx.Value(workerKey{}).(int)
+	return id
+}
+
+// WithWorkerOffset returns ctx whose threads are numbered from offset+1, e.g. to keep the worker ids
+// of the scenarios running at the same time apart
+func WithWorkerOffset(ctx context.Context, offset int) context.Context {
+	return context.WithValue(ctx, workerOffsetKey{}, offset)
+}
+
+// WorkerOffset returns the offset of the worker ids of the threads started with ctx, see WithWorkerOffset
+func WorkerOffset(ctx context.Context) int {
+	offset, _ := ctx.Value(workerOffsetKey{}).(int)
+	return offset
+}
+
+func withIteration(ctx context.Context, iteration int) context.Context {
+	return context.WithValue(ctx, iterationKey{}, iteration)
+}
+
+// Iteration returns the 0-based iteration of the task of ctx, counted by thread in RunSync,
+// and by schedule in RunArrivalRate
+func Iteration(ctx context.Context) int {
+	iteration, _ := ctx.Value(iterationKey{}).(int)
+	return iteration
+}
+
+// WithScenario returns ctx of the tasks of scenario name, their results are reported by scenario
+func WithScenario(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, scenarioKey{}, name)
+}
+
+// ScenarioName returns the scenario of the task of ctx, empty when the task is not in a scenario
+func ScenarioName(ctx context.Context) string {
+	name, _ := ctx.Value(scenarioKey{}).(string)
+	return name
+}
@@ -0,0 +1,233 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/ginkgoch/stress-test/pkg/client/runner"
+	"github.com/ginkgoch/stress-test/pkg/client/statistics"
+	"go.uber.org/ratelimit"
+)
+
+// Scenario is one named workload of RunScenarios. The scenarios setting neither ConcurrentNum nor Rate share
+// the threads, the limitation and the rate of the client in proportion to their weights.
+// A scenario with a rate runs in the open model as RunWithArrivalRate, otherwise as RunSingleTaskWithRateLimiter.
+type Scenario struct {
+	Name string
+	// Weight is the share of the client, 0 means 1
+	Weight float64
+	// ConcurrentNum is the thread number, or the pre-allocated thread number of the open model
+	ConcurrentNum int
+	// Limitation is the task(s) per second shared by the threads of the scenario, 0 means unlimited
+	Limitation int
+	// Rate is the task(s) arriving per second of the open model
+	Rate float64
+	// MaxConcurrentNum is the thread number the open model of the scenario is allowed to grow to
+	MaxConcurrentNum int
+	// TaskFunc runs one task of category Name
+	TaskFunc func(ctx context.Context) error
+	// MultiTaskFunc runs one iteration of the tasks writing their own results, it does not support the open model
+	MultiTaskFunc func(ctx context.Context, ch chan<- *runner.TaskResult) error
+}
+
+// RunScenarios runs the scenarios at the same time, the results share one statistics and are reported by scenario.
+// Number and Duration apply to every scenario, Stages are not supported. The worker ids are unique across the scenarios,
+// the threads of the first scenario are 1 to n, the threads of the next one start at n+1
+func (s *StressTestClient) RunScenarios(ctx context.Context, scenarios []*Scenario) (*statistics.ResultStatistics, error) {
+	resolved, err := s.ResolveScenarios(scenarios)
+	if err != nil {
+		return nil, err
+	}
+
+	st := s.newStatistics()
+	st.ConcurrentNum = 0
+	for _, sc := range resolved {
+		st.ConcurrentNum += sc.ConcurrentNum
+		if sc.Rate > 0 {
+			st.TargetRate += sc.Rate
+			st.OpenModel = true
+		} else {
+			st.TargetRate += float64(sc.Limitation)
+		}
+	}
+
+	s.runInternal(ctx, st, func(ctx context.Context, ch chan<- *runner.TaskResult) {
+		wg := new(sync.WaitGroup)
+		// the worker ids go on from the threads of the previous scenarios, e.g. for the feeders and the virtual users
+		offset := 0
+		for _, sc := range resolved {
+			wg.Add(1)
+			go func(ctx context.Context, sc *Scenario) {
+				defer wg.Done()
+				s.runScenario(ctx, sc, ch)
+			}(runner.WithWorkerOffset(runner.WithScenario(ctx, sc.Name), offset), sc)
+
+			offset += sc.threads()
+		}
+		wg.Wait()
+	})
+
+	return st, nil
+}
+
+// ResolveScenarios returns copies of the scenarios with the threads, the limitations and the rates shared by weight
+func (s *StressTestClient) ResolveScenarios(scenarios []*Scenario) ([]*Scenario, error) {
+	if len(scenarios) == 0 {
+		return nil, fmt.Errorf("no scenario to run")
+	}
+
+	if len(s.Stages) > 0 {
+		return nil, fmt.Errorf("stages are not supported with scenarios")
+	}
+
+	names := make(map[string]bool)
+	totalWeight := 0.0
+	for _, sc := range scenarios {
+		if sc.Name == "" || names[sc.Name] {
+			return nil, fmt.Errorf("scenario name <%s> is empty or duplicated", sc.Name)
+		}
+
+		if (sc.TaskFunc == nil) == (sc.MultiTaskFunc == nil) {
+			return nil, fmt.Errorf("scenario <%s> needs either TaskFunc or MultiTaskFunc", sc.Name)
+		}
+
+		if sc.Weight < 0 {
+			return nil, fmt.Errorf("scenario <%s> has a negative weight", sc.Name)
+		}
+
+		names[sc.Name] = true
+		if weighted(sc) {
+			totalWeight += weightOf(sc)
+		}
+	}
+
+	var result []*Scenario
+	for _, sc := range scenarios {
+		r := *sc
+
+		if weighted(sc) {
+			share := weightOf(sc) / totalWeight
+			if s.Rate > 0 {
+				r.Rate = s.Rate * share
+			}
+
+			r.ConcurrentNum = shareOf(s.ConcurrentNum, share)
+			if r.MaxConcurrentNum == 0 {
+				r.MaxConcurrentNum = shareOf(s.maxConcurrentNum(), share)
+			}
+
+			if r.Limitation == 0 && s.Limitation > 0 {
+				r.Limitation = shareOf(s.Limitation, share)
+			}
+		}
+
+		if r.ConcurrentNum == 0 {
+			r.ConcurrentNum = 1
+		}
+
+		if r.Rate > 0 {
+			if r.MultiTaskFunc != nil {
+				return nil, fmt.Errorf("scenario <%s> of multiple tasks does not support the arrival rate", sc.Name)
+			}
+
+			if r.MaxConcurrentNum == 0 {
+				r.MaxConcurrentNum = s.maxConcurrentNum()
+			}
+
+			if r.MaxConcurrentNum < r.ConcurrentNum {
+				r.MaxConcurrentNum = r.ConcurrentNum
+			}
+		}
+
+		result = append(result, &r)
+	}
+
+	return result, nil
+}
+
+// ScenariosHeader prints the resolved scenarios before the run
+func (s *StressTestClient) ScenariosHeader(scenarios []*Scenario) {
+	if !s.printsTable() {
+		return
+	}
+
+	var msgs []string
+	for _, sc := range scenarios {
+		if sc.Rate > 0 {
+			msgs = append(msgs, fmt.Sprintf("%s %.2f task(s) per second with %d-%d thread(s)", sc.Name, sc.Rate, sc.ConcurrentNum, sc.MaxConcurrentNum))
+		} else if sc.Limitation > 0 {
+			msgs = append(msgs, fmt.Sprintf("%s %d thread(s), %d task(s) limitation per second", sc.Name, sc.ConcurrentNum, sc.Limitation))
+		} else {
+			msgs = append(msgs, fmt.Sprintf("%s %d thread(s)", sc.Name, sc.ConcurrentNum))
+		}
+	}
+
+	if s.Duration > 0 {
+		fmt.Printf("%d scenario(s) ready to run for %v: %s\n", len(scenarios), s.Duration, strings.Join(msgs, ", "))
+	} else {
+		fmt.Printf("%d scenario(s) ready to run %d iteration(s) per thread: %s\n", len(scenarios), s.Number, strings.Join(msgs, ", "))
+	}
+
+	fmt.Println()
+}
+
+func (s *StressTestClient) runScenario(ctx context.Context, sc *Scenario, ch chan<- *runner.TaskResult) {
+	if sc.Rate > 0 {
+		num := s.iterations() * sc.ConcurrentNum
+		runner.RunArrivalRate(ctx, sc.Name, num, sc.Rate, s.Poisson, sc.ConcurrentNum, sc.MaxConcurrentNum, ch, sc.TaskFunc)
+		return
+	}
+
+	var rateLimiter ratelimit.Limiter
+	if sc.Limitation > 0 {
+		rateLimiter = ratelimit.New(sc.Limitation)
+	}
+
+	wg := new(sync.WaitGroup)
+	num := s.iterations()
+	for i := 0; i < sc.ConcurrentNum; i++ {
+		wg.Add(1)
+		workerCtx := runner.WithWorker(ctx, runner.WorkerOffset(ctx)+i+1)
+		if sc.MultiTaskFunc != nil {
+			go runner.RunSyncWithMultiTasks(workerCtx, num, rateLimiter, ch, wg, sc.MultiTaskFunc)
+		} else {
+			go runner.RunSync(workerCtx, sc.Name, num, rateLimiter, ch, wg, sc.TaskFunc)
+		}
+	}
+	wg.Wait()
+}
+
+// threads returns the most threads the scenario may start
+func (sc *Scenario) threads() int {
+	if sc.Rate > 0 {
+		return sc.MaxConcurrentNum
+	}
+
+	return sc.ConcurrentNum
+}
+
+// weighted tells whether the scenario takes a share of the client, it does when it sets neither threads nor rate
+func weighted(sc *Scenario) bool {
+	return sc.ConcurrentNum == 0 && sc.Rate == 0
+}
+
+func weightOf(sc *Scenario) float64 {
+	if sc.Weight == 0 {
+		return 1
+	}
+
+	return sc.Weight
+}
+
+// shareOf returns the share of total, at least 1
+func shareOf(total int, share float64) int {
+	n := int(math.Round(float64(total) * share))
+	if n < 1 {
+		return 1
+	}
+
+	return n
+}
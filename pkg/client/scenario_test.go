@@ -0,0 +1,138 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/ginkgoch/stress-test/pkg/client/runner"
+	"github.com/ginkgoch/stress-test/pkg/client/statistics"
+)
+
+func noop(ctx context.Context) error {
+	return nil
+}
+
+func TestResolveScenarios(t *testing.T) {
+	type resolved struct {
+		concurrent, max, limitation int
+		rate                        float64
+	}
+
+	cases := []struct {
+		name      string
+		client    *StressTestClient
+		scenarios []*Scenario
+		expected  []resolved
+	}{
+		{
+			"threads and limitation by weight",
+			NewStressClient(1, 10, 100),
+			[]*Scenario{{Name: "a", Weight: 3, TaskFunc: noop}, {Name: "b", TaskFunc: noop}, {Name: "c", ConcurrentNum: 2, TaskFunc: noop}},
+			[]resolved{{8, 8, 75, 0}, {3, 3, 25, 0}, {2, 0, 0, 0}},
+		},
+		{
+			"rate by weight",
+			NewStressClientWithArrivalRate(1, 100, 4, 40),
+			[]*Scenario{{Name: "a", TaskFunc: noop}, {Name: "b", Weight: 3, TaskFunc: noop}},
+			[]resolved{{1, 10, 0, 25}, {3, 30, 0, 75}},
+		},
+		{
+			"own rate",
+			NewStressClientWithConcurrentNumber(1, 4),
+			[]*Scenario{{Name: "a", TaskFunc: noop}, {Name: "b", Rate: 10, TaskFunc: noop}},
+			[]resolved{{4, 4, 0, 0}, {1, 4, 0, 10}},
+		},
+		{
+			"at least one thread",
+			NewStressClientWithConcurrentNumber(1, 1),
+			[]*Scenario{{Name: "a", TaskFunc: noop}, {Name: "b", Weight: 9, TaskFunc: noop}},
+			[]resolved{{1, 1, 0, 0}, {1, 1, 0, 0}},
+		},
+	}
+
+	for _, c := range cases {
+		result, err := c.client.ResolveScenarios(c.scenarios)
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+
+		for i, sc := range result {
+			actual := resolved{sc.ConcurrentNum, sc.MaxConcurrentNum, sc.Limitation, sc.Rate}
+			if actual != c.expected[i] {
+				t.Errorf("%s: scenario %s is %+v, want %+v", c.name, sc.Name, actual, c.expected[i])
+			}
+
+			if sc == c.scenarios[i] {
+				t.Errorf("%s: scenario %s is not copied", c.name, sc.Name)
+			}
+		}
+	}
+}
+
+func TestResolveScenariosFails(t *testing.T) {
+	multiTask := func(ctx context.Context, ch chan<- *runner.TaskResult) error { return nil }
+	staged := NewStressClientWithConcurrentNumber(1, 1)
+	staged.Stages = []Stage{{Duration: 1, Target: 1}}
+
+	cases := []struct {
+		name      string
+		client    *StressTestClient
+		scenarios []*Scenario
+	}{
+		{"no scenario", NewStressClientWithNumber(1), nil},
+		{"stages", staged, []*Scenario{{Name: "a", TaskFunc: noop}}},
+		{"empty name", NewStressClientWithNumber(1), []*Scenario{{TaskFunc: noop}}},
+		{"duplicated name", NewStressClientWithNumber(1), []*Scenario{{Name: "a", TaskFunc: noop}, {Name: "a", TaskFunc: noop}}},
+		{"no task", NewStressClientWithNumber(1), []*Scenario{{Name: "a"}}},
+		{"both tasks", NewStressClientWithNumber(1), []*Scenario{{Name: "a", TaskFunc: noop, MultiTaskFunc: multiTask}}},
+		{"negative weight", NewStressClientWithNumber(1), []*Scenario{{Name: "a", Weight: -1, TaskFunc: noop}}},
+		{"multiple tasks with rate", NewStressClientWithArrivalRate(1, 10, 1, 1), []*Scenario{{Name: "a", MultiTaskFunc: multiTask}}},
+	}
+
+	for _, c := range cases {
+		if _, err := c.client.ResolveScenarios(c.scenarios); err == nil {
+			t.Errorf("%s: ResolveScenarios should fail", c.name)
+		}
+	}
+}
+
+func TestRunScenarios(t *testing.T) {
+	var locker sync.Mutex
+	workers := make(map[string]map[int]int)
+	record := func(ctx context.Context) error {
+		locker.Lock()
+		defer locker.Unlock()
+
+		name := runner.ScenarioName(ctx)
+		if workers[name] == nil {
+			workers[name] = make(map[int]int)
+		}
+
+		workers[name][runner.WorkerID(ctx)]++
+		return nil
+	}
+
+	s := NewStressClientWithConcurrentNumber(5, 4)
+	s.Reporters = []statistics.Reporter{}
+
+	st, err := s.RunScenarios(context.Background(), []*Scenario{{Name: "a", TaskFunc: record}, {Name: "b", TaskFunc: record}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if st.SuccessNum != 20 {
+		t.Errorf("%d success, want 20", st.SuccessNum)
+	}
+
+	// the worker ids of the second scenario go on from the first one
+	expected := map[string]map[int]int{"a": {1: 5, 2: 5}, "b": {3: 5, 4: 5}}
+	for name, ids := range expected {
+		for id, n := range ids {
+			if workers[name][id] != n {
+				t.Errorf("scenario %s worker %d ran %d task(s), want %d - %v", name, id, workers[name][id], n, workers)
+			}
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package sink
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	influxMeasurement = "stress_test"
+)
+
+// InfluxPusher posts the points in InfluxDB line protocol, e.g. to http://localhost:8086/write?db=stress
+type InfluxPusher struct {
+	URL string
+	// Token is sent as "Authorization: Token <token>" when it is not empty
+	Token      string
+	httpClient *http.Client
+}
+
+func NewInfluxPusher(url string, token string) *InfluxPusher {
+	return &InfluxPusher{
+		URL:        url,
+		Token:      token,
+		httpClient: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+func (p *InfluxPusher) Push(points []*PushPoint, tags map[string]string) error {
+	body := new(bytes.Buffer)
+	for _, point := range points {
+		writeInfluxLine(body, point, tags)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, p.URL, body)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if p.Token != "" {
+		req.Header.Set("Authorization", "Token "+p.Token)
+	}
+
+	res, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+	io.Copy(ioutil.Discard, res.Body)
+
+	if res.StatusCode/100 != 2 {
+		return fmt.Errorf("influxdb responded status code <%d>", res.StatusCode)
+	}
+
+	return nil
+}
+
+func (p *InfluxPusher) Close() error {
+	p.httpClient.CloseIdleConnections()
+	return nil
+}
+
+// writeInfluxLine writes e.g. stress_test,category=curl,host=a success=10i,failure=0i,dropped=0i,mean=1.2,max=3.4,p95=2.1,p99=3.4 1600000000000000000
+func writeInfluxLine(buf *bytes.Buffer, point *PushPoint, tags map[string]string) {
+	buf.WriteString(influxMeasurement)
+
+	allTags := map[string]string{"category": point.Category}
+	for key, value := range tags {
+		allTags[key] = value
+	}
+
+	for _, key := range sortedTagKeys(allTags) {
+		if allTags[key] == "" {
+			continue
+		}
+
+		buf.WriteByte(',')
+		buf.WriteString(influxEscaper.Replace(key))
+		buf.WriteByte('=')
+		buf.WriteString(influxEscaper.Replace(allTags[key]))
+	}
+
+	fmt.Fprintf(buf, " success=%di,failure=%di,dropped=%di", point.SuccessNum, point.FailureNum, point.DroppedNum)
+	if point.TotalNum() > 0 {
+		buf.WriteString(",mean=" + formatFloat(point.MeanTime))
+		buf.WriteString(",max=" + formatFloat(point.MaxTime))
+		buf.WriteString(",p95=" + formatFloat(point.P95))
+		buf.WriteString(",p99=" + formatFloat(point.P99))
+	}
+
+	fmt.Fprintf(buf, " %d\n", point.Time.UnixNano())
+}
+
+var influxEscaper = strings.NewReplacer(`,`, `\,`, `=`, `\=`, ` `, `\ `, "\n", ``)
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
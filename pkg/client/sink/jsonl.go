@@ -0,0 +1,70 @@
+package sink
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+
+	"github.com/ginkgoch/stress-test/pkg/client/runner"
+)
+
+// JSONLSink writes every result as one compact json line into a buffered file
+type JSONLSink struct {
+	file    *os.File
+	writer  *bufio.Writer
+	encoder *json.Encoder
+}
+
+func NewJSONLSink(filepath string) (*JSONLSink, error) {
+	file, err := os.Create(filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	writer := bufio.NewWriterSize(file, 64*1024)
+	return &JSONLSink{
+		file:    file,
+		writer:  writer,
+		encoder: json.NewEncoder(writer),
+	}, nil
+}
+
+func (s *JSONLSink) Write(r *runner.TaskResult) error {
+	return s.encoder.Encode(r)
+}
+
+// Flush writes the buffered lines into the file
+func (s *JSONLSink) Flush() error {
+	return s.writer.Flush()
+}
+
+func (s *JSONLSink) Close() error {
+	if err := s.writer.Flush(); err != nil {
+		s.file.Close()
+		return err
+	}
+
+	return s.file.Close()
+}
+
+// ReadJSONL reads the results written by JSONLSink, empty lines are skipped
+func ReadJSONL(filepath string, handler func(r *runner.TaskResult)) error {
+	file, err := os.Open(filepath)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	decoder := json.NewDecoder(bufio.NewReaderSize(file, 64*1024))
+	for decoder.More() {
+		r := new(runner.TaskResult)
+		if err = decoder.Decode(r); err != nil {
+			return err
+		}
+
+		handler(r)
+	}
+
+	return nil
+}
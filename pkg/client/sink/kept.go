@@ -0,0 +1,30 @@
+package sink
+
+import (
+	"github.com/ginkgoch/stress-test/pkg/client/runner"
+	"github.com/ginkgoch/stress-test/pkg/client/statistics"
+)
+
+// KeptSink keeps Sink open for the following runs of the process, e.g. talent --endless.
+// Closing it at the end of a run only flushes Sink, the process exit releases it
+type KeptSink struct {
+	Sink statistics.ResultSink
+}
+
+func (s *KeptSink) SetStatistics(st *statistics.ResultStatistics) {
+	if aware, ok := s.Sink.(statistics.StatisticsAware); ok {
+		aware.SetStatistics(st)
+	}
+}
+
+func (s *KeptSink) Write(r *runner.TaskResult) error {
+	return s.Sink.Write(r)
+}
+
+func (s *KeptSink) Close() error {
+	if flusher, ok := s.Sink.(interface{ Flush() error }); ok {
+		return flusher.Flush()
+	}
+
+	return nil
+}
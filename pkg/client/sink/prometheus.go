@@ -0,0 +1,185 @@
+package sink
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ginkgoch/stress-test/pkg/client/runner"
+	"github.com/ginkgoch/stress-test/pkg/client/statistics"
+)
+
+var (
+	// PrometheusBuckets are the upper bounds in seconds of the latency histogram
+	PrometheusBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+)
+
+// PrometheusSink serves the live metrics in prometheus text exposition format on /metrics.
+// Keep one sink for the runs of a process, e.g. with KeptSink, so the counters only grow
+type PrometheusSink struct {
+	server     *http.Server
+	st         *statistics.ResultStatistics
+	categories map[string]*prometheusCategory
+	locker     sync.Mutex
+}
+
+type prometheusCategory struct {
+	outcomes map[string]uint64
+	errors   map[string]uint64
+	buckets  []uint64
+	sum      float64
+	count    uint64
+}
+
+// NewPrometheusSink starts serving on addr, e.g. ":9102", until the sink is closed
+func NewPrometheusSink(addr string) (*PrometheusSink, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &PrometheusSink{categories: make(map[string]*prometheusCategory)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", s.serveMetrics)
+	s.server = &http.Server{Handler: mux}
+
+	go s.server.Serve(listener)
+	return s, nil
+}
+
+func (s *PrometheusSink) SetStatistics(st *statistics.ResultStatistics) {
+	s.locker.Lock()
+	defer s.locker.Unlock()
+
+	s.st = st
+}
+
+func (s *PrometheusSink) Write(r *runner.TaskResult) error {
+	s.locker.Lock()
+	defer s.locker.Unlock()
+
+	category, ok := s.categories[r.Category]
+	if !ok {
+		category = &prometheusCategory{
+			outcomes: make(map[string]uint64),
+			errors:   make(map[string]uint64),
+			buckets:  make([]uint64, len(PrometheusBuckets)),
+		}
+		s.categories[r.Category] = category
+	}
+
+	switch {
+	case r.Dropped:
+		category.outcomes["dropped"]++
+		return nil
+	case r.Success:
+		category.outcomes["success"]++
+	default:
+		category.outcomes["failure"]++
+
+		class := r.ErrClass
+		if class == "" {
+			class = runner.ErrorClassOther
+		}
+		category.errors[class]++
+	}
+
+	seconds := float64(r.ProcessTime) / 1e9
+	for i, bound := range PrometheusBuckets {
+		if seconds <= bound {
+			category.buckets[i]++
+		}
+	}
+
+	category.sum += seconds
+	category.count++
+	return nil
+}
+
+func (s *PrometheusSink) Close() error {
+	return s.server.Close()
+}
+
+func (s *PrometheusSink) serveMetrics(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	writer := bufio.NewWriter(w)
+	defer writer.Flush()
+
+	s.locker.Lock()
+	defer s.locker.Unlock()
+
+	var names []string
+	for name := range s.categories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	writeMetricHeader(writer, "stress_test_requests_total", "counter", "Finished tasks by category and outcome.")
+	for _, name := range names {
+		category := s.categories[name]
+		for _, outcome := range []string{"success", "failure", "dropped"} {
+			fmt.Fprintf(writer, "stress_test_requests_total{category=\"%s\",outcome=\"%s\"} %d\n", escapeLabel(name), outcome, category.outcomes[outcome])
+		}
+	}
+
+	writeMetricHeader(writer, "stress_test_errors_total", "counter", "Failed tasks by category and error class.")
+	for _, name := range names {
+		category := s.categories[name]
+
+		var classes []string
+		for class := range category.errors {
+			classes = append(classes, class)
+		}
+		sort.Strings(classes)
+
+		for _, class := range classes {
+			fmt.Fprintf(writer, "stress_test_errors_total{category=\"%s\",class=\"%s\"} %d\n", escapeLabel(name), escapeLabel(class), category.errors[class])
+		}
+	}
+
+	writeMetricHeader(writer, "stress_test_request_duration_seconds", "histogram", "Process time of the tasks by category.")
+	for _, name := range names {
+		category := s.categories[name]
+		label := escapeLabel(name)
+		for i, bound := range PrometheusBuckets {
+			fmt.Fprintf(writer, "stress_test_request_duration_seconds_bucket{category=\"%s\",le=\"%v\"} %d\n", label, bound, category.buckets[i])
+		}
+
+		fmt.Fprintf(writer, "stress_test_request_duration_seconds_bucket{category=\"%s\",le=\"+Inf\"} %d\n", label, category.count)
+		fmt.Fprintf(writer, "stress_test_request_duration_seconds_sum{category=\"%s\"} %v\n", label, category.sum)
+		fmt.Fprintf(writer, "stress_test_request_duration_seconds_count{category=\"%s\"} %d\n", label, category.count)
+	}
+
+	writeMetricHeader(writer, "stress_test_active_tasks", "gauge", "Tasks running right now.")
+	fmt.Fprintf(writer, "stress_test_active_tasks %d\n", runner.ActiveTasks())
+
+	if s.st != nil {
+		concurrentNum, targetRate, rate := s.st.Gauges()
+
+		writeMetricHeader(writer, "stress_test_concurrency", "gauge", "Target concurrency of the run.")
+		fmt.Fprintf(writer, "stress_test_concurrency %d\n", concurrentNum)
+
+		writeMetricHeader(writer, "stress_test_target_rate", "gauge", "Target tasks per second, 0 means unlimited.")
+		fmt.Fprintf(writer, "stress_test_target_rate %v\n", targetRate)
+
+		writeMetricHeader(writer, "stress_test_rate", "gauge", "Finished tasks in the last second.")
+		fmt.Fprintf(writer, "stress_test_rate %v\n", rate)
+	}
+}
+
+func writeMetricHeader(writer *bufio.Writer, name string, metricType string, help string) {
+	fmt.Fprintf(writer, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(writer, "# TYPE %s %s\n", name, metricType)
+}
+
+var labelReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(value string) string {
+	return labelReplacer.Replace(value)
+}
@@ -0,0 +1,203 @@
+package sink
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ginkgoch/stress-test/pkg/client/runner"
+	"github.com/ginkgoch/stress-test/pkg/client/statistics"
+	"github.com/ginkgoch/stress-test/pkg/log"
+)
+
+// PushPoint is the aggregation of one category in one second
+type PushPoint struct {
+	Time       time.Time
+	Category   string
+	SuccessNum int
+	FailureNum int
+	DroppedNum int
+	// MeanTime, MaxTime and the percentiles are in ms
+	MeanTime float64
+	MaxTime  float64
+	P95      float64
+	P99      float64
+}
+
+func (p *PushPoint) TotalNum() int {
+	return p.SuccessNum + p.FailureNum
+}
+
+// Pusher sends the points of the finished seconds to a metrics backend, see InfluxPusher and StatsDPusher
+type Pusher interface {
+	Push(points []*PushPoint, tags map[string]string) error
+	Close() error
+}
+
+// PushSink aggregates the results per second and category, and pushes them every second in the background
+type PushSink struct {
+	pusher  Pusher
+	tags    map[string]string
+	buckets map[pushKey]*pushBucket
+	done    chan struct{}
+	stopped chan struct{}
+	locker  sync.Mutex
+}
+
+type pushKey struct {
+	second   int64
+	category string
+}
+
+type pushBucket struct {
+	successNum  int
+	failureNum  int
+	droppedNum  int
+	processTime uint64
+	histogram   *statistics.Histogram
+}
+
+var (
+	pushInterval = time.Second
+)
+
+// NewPushSink pushes with pusher, tags are attached to every point besides the category
+func NewPushSink(pusher Pusher, tags map[string]string) *PushSink {
+	s := &PushSink{
+		pusher:  pusher,
+		tags:    tags,
+		buckets: make(map[pushKey]*pushBucket),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+
+	go s.loop()
+	return s
+}
+
+func (s *PushSink) Write(r *runner.TaskResult) error {
+	key := pushKey{second: time.Now().Unix(), category: r.Category}
+
+	s.locker.Lock()
+	defer s.locker.Unlock()
+
+	bucket, ok := s.buckets[key]
+	if !ok {
+		bucket = &pushBucket{histogram: statistics.NewHistogram()}
+		s.buckets[key] = bucket
+	}
+
+	switch {
+	case r.Dropped:
+		bucket.droppedNum++
+		return nil
+	case r.Success:
+		bucket.successNum++
+	default:
+		bucket.failureNum++
+	}
+
+	bucket.processTime += r.ProcessTime
+	bucket.histogram.Record(r.ProcessTime)
+	return nil
+}
+
+// Close pushes the rest points, including the current second
+func (s *PushSink) Close() error {
+	close(s.done)
+	<-s.stopped
+
+	err := s.flush(false)
+	if closeErr := s.pusher.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+func (s *PushSink) loop() {
+	defer close(s.stopped)
+
+	ticker := time.NewTicker(pushInterval)
+	defer ticker.Stop()
+
+	// only the first failure is printed, the live table is not flooded when the backend is down
+	failed := false
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.flush(true); err != nil {
+				if !failed {
+					fmt.Fprintln(os.Stderr, "push metrics failed -", err)
+					failed = true
+				}
+
+				log.Println("push metrics failed -", err)
+			}
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// flush pushes the buckets of the finished seconds, or all of them when finishedOnly is false
+func (s *PushSink) flush(finishedOnly bool) error {
+	current := time.Now().Unix()
+
+	s.locker.Lock()
+	var points []*PushPoint
+	for key, bucket := range s.buckets {
+		if finishedOnly && key.second >= current {
+			continue
+		}
+
+		points = append(points, bucket.point(key))
+		delete(s.buckets, key)
+	}
+	s.locker.Unlock()
+
+	if len(points) == 0 {
+		return nil
+	}
+
+	sort.Slice(points, func(i, j int) bool {
+		if !points[i].Time.Equal(points[j].Time) {
+			return points[i].Time.Before(points[j].Time)
+		}
+
+		return points[i].Category < points[j].Category
+	})
+
+	return s.pusher.Push(points, s.tags)
+}
+
+func (bucket *pushBucket) point(key pushKey) *PushPoint {
+	p := &PushPoint{
+		Time:       time.Unix(key.second, 0),
+		Category:   key.category,
+		SuccessNum: bucket.successNum,
+		FailureNum: bucket.failureNum,
+		DroppedNum: bucket.droppedNum,
+	}
+
+	if total := p.TotalNum(); total > 0 {
+		p.MeanTime = float64(bucket.processTime) / float64(total) / 1e6
+		p.MaxTime = float64(bucket.histogram.Max) / 1e6
+		p.P95 = float64(bucket.histogram.Percentile(95)) / 1e6
+		p.P99 = float64(bucket.histogram.Percentile(99)) / 1e6
+	}
+
+	return p
+}
+
+func sortedTagKeys(tags map[string]string) []string {
+	var keys []string
+	for key := range tags {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,144 @@
+package sink
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testPoints() []*PushPoint {
+	return []*PushPoint{
+		{Time: time.Unix(1600000000, 0), Category: "start game", SuccessNum: 10, FailureNum: 2, MeanTime: 1.5, MaxTime: 4, P95: 3.25, P99: 4},
+		{Time: time.Unix(1600000001, 0), Category: "curl", DroppedNum: 3},
+	}
+}
+
+func TestInfluxPusher(t *testing.T) {
+	var body, auth, contentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		data, _ := ioutil.ReadAll(req.Body)
+		body, auth, contentType = string(data), req.Header.Get("Authorization"), req.Header.Get("Content-Type")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	pusher := NewInfluxPusher(server.URL+"/write?db=stress", "secret")
+	defer pusher.Close()
+
+	if err := pusher.Push(testPoints(), map[string]string{"run_id": "r1", "env": "a=b,c", "empty": ""}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `stress_test,category=start\ game,env=a\=b\,c,run_id=r1 success=10i,failure=2i,dropped=0i,mean=1.5,max=4,p95=3.25,p99=4 1600000000000000000
+stress_test,category=curl,env=a\=b\,c,run_id=r1 success=0i,failure=0i,dropped=3i 1600000001000000000
+`
+	if body != expected {
+		t.Errorf("body is\n%s\nwant\n%s", body, expected)
+	}
+
+	if auth != "Token secret" {
+		t.Errorf("authorization is %q", auth)
+	}
+
+	if !strings.HasPrefix(contentType, "text/plain") {
+		t.Errorf("content type is %q", contentType)
+	}
+}
+
+func TestInfluxPusherStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	pusher := NewInfluxPusher(server.URL, "")
+	defer pusher.Close()
+
+	if err := pusher.Push(testPoints(), nil); err == nil {
+		t.Error("push should fail with status 400")
+	}
+}
+
+func TestStatsDPusher(t *testing.T) {
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	pusher, err := NewStatsDPusher(listener.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pusher.Close()
+
+	if err = pusher.Push(testPoints(), map[string]string{"run_id": "r1", "env": "a:b", "empty": ""}); err != nil {
+		t.Fatal(err)
+	}
+
+	listener.SetReadDeadline(time.Now().Add(5 * time.Second))
+	packet := make([]byte, statsdPacketSize)
+	n, _, err := listener.ReadFrom(packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := strings.Join([]string{
+		"stress_test.requests:10|c|#category:start game,env:a_b,run_id:r1,outcome:success",
+		"stress_test.requests:2|c|#category:start game,env:a_b,run_id:r1,outcome:failure",
+		"stress_test.latency.mean:1.5|g|#category:start game,env:a_b,run_id:r1",
+		"stress_test.latency.max:4|g|#category:start game,env:a_b,run_id:r1",
+		"stress_test.latency.p95:3.25|g|#category:start game,env:a_b,run_id:r1",
+		"stress_test.latency.p99:4|g|#category:start game,env:a_b,run_id:r1",
+		"stress_test.requests:0|c|#category:curl,env:a_b,run_id:r1,outcome:success",
+		"stress_test.requests:0|c|#category:curl,env:a_b,run_id:r1,outcome:failure",
+		"stress_test.requests:3|c|#category:curl,env:a_b,run_id:r1,outcome:dropped",
+	}, "\n")
+
+	if string(packet[:n]) != expected {
+		t.Errorf("packet is\n%s\nwant\n%s", packet[:n], expected)
+	}
+}
+
+func TestStatsDPusherPacketSize(t *testing.T) {
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	pusher, err := NewStatsDPusher(listener.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pusher.Close()
+
+	var points []*PushPoint
+	for i := 0; i < 50; i++ {
+		points = append(points, testPoints()[0])
+	}
+
+	if err = pusher.Push(points, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := 0
+	packet := make([]byte, 64*1024)
+	for lines < 50*6 {
+		listener.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, _, err := listener.ReadFrom(packet)
+		if err != nil {
+			t.Fatalf("%d line(s) received - %v", lines, err)
+		}
+
+		if n > statsdPacketSize {
+			t.Errorf("packet of %d bytes is larger than %d", n, statsdPacketSize)
+		}
+
+		lines += strings.Count(string(packet[:n]), "\n") + 1
+	}
+}
@@ -0,0 +1,98 @@
+package sink
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strings"
+)
+
+const (
+	statsdPrefix = "stress_test"
+	// statsdPacketSize keeps a packet in one ethernet frame
+	statsdPacketSize = 1432
+)
+
+// StatsDPusher sends the points over udp as statsd counters and gauges, the tags are in dogstatsd format
+type StatsDPusher struct {
+	conn net.Conn
+}
+
+func NewStatsDPusher(addr string) (*StatsDPusher, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StatsDPusher{conn: conn}, nil
+}
+
+// Push sends e.g. stress_test.requests:10|c|#category:curl,outcome:success and stress_test.latency.p95:2.1|g|#category:curl
+func (p *StatsDPusher) Push(points []*PushPoint, tags map[string]string) error {
+	var lines []string
+	for _, point := range points {
+		pointTags := statsdTags(point.Category, tags)
+
+		lines = append(lines,
+			fmt.Sprintf("%s.requests:%d|c|#%s,outcome:success", statsdPrefix, point.SuccessNum, pointTags),
+			fmt.Sprintf("%s.requests:%d|c|#%s,outcome:failure", statsdPrefix, point.FailureNum, pointTags),
+		)
+
+		if point.DroppedNum > 0 {
+			lines = append(lines, fmt.Sprintf("%s.requests:%d|c|#%s,outcome:dropped", statsdPrefix, point.DroppedNum, pointTags))
+		}
+
+		if point.TotalNum() > 0 {
+			lines = append(lines,
+				fmt.Sprintf("%s.latency.mean:%s|g|#%s", statsdPrefix, formatFloat(point.MeanTime), pointTags),
+				fmt.Sprintf("%s.latency.max:%s|g|#%s", statsdPrefix, formatFloat(point.MaxTime), pointTags),
+				fmt.Sprintf("%s.latency.p95:%s|g|#%s", statsdPrefix, formatFloat(point.P95), pointTags),
+				fmt.Sprintf("%s.latency.p99:%s|g|#%s", statsdPrefix, formatFloat(point.P99), pointTags),
+			)
+		}
+	}
+
+	packet := new(bytes.Buffer)
+	for _, line := range lines {
+		if packet.Len() > 0 && packet.Len()+len(line)+1 > statsdPacketSize {
+			if _, err := p.conn.Write(packet.Bytes()); err != nil {
+				return err
+			}
+
+			packet.Reset()
+		}
+
+		if packet.Len() > 0 {
+			packet.WriteByte('\n')
+		}
+
+		packet.WriteString(line)
+	}
+
+	if packet.Len() > 0 {
+		if _, err := p.conn.Write(packet.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *StatsDPusher) Close() error {
+	return p.conn.Close()
+}
+
+func statsdTags(category string, tags map[string]string) string {
+	result := []string{"category:" + statsdEscaper.Replace(category)}
+	for _, key := range sortedTagKeys(tags) {
+		if tags[key] == "" {
+			continue
+		}
+
+		result = append(result, statsdEscaper.Replace(key)+":"+statsdEscaper.Replace(tags[key]))
+	}
+
+	return strings.Join(result, ",")
+}
+
+var statsdEscaper = strings.NewReplacer(`,`, `_`, `|`, `_`, `#`, `_`, ":", "_", "\n", ``)
@@ -0,0 +1,137 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Stage moves the target concurrency, or the target rate of the open model, linearly to Target within Duration
+type Stage struct {
+	Duration time.Duration
+	Target   float64
+}
+
+func (stage *Stage) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Duration string  `json:"duration"`
+		Target   float64 `json:"target"`
+	}
+
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	d, err := time.ParseDuration(raw.Duration)
+	if err != nil {
+		return err
+	}
+
+	stage.Duration = d
+	stage.Target = raw.Target
+	return validateStage(stage)
+}
+
+func (stage *Stage) String() string {
+	return fmt.Sprintf("%v:%v", stage.Duration, stage.Target)
+}
+
+// validateStage returns the error of a stage without a positive duration or with a negative target,
+// the stages of ParseStages and of the json files are checked the same way
+func validateStage(stage *Stage) error {
+	if stage.Duration <= 0 {
+		return fmt.Errorf("stage <%v> duration must be greater than 0", stage)
+	}
+
+	if stage.Target < 0 || math.IsNaN(stage.Target) {
+		return fmt.Errorf("stage <%v> target must not be negative", stage)
+	}
+
+	return nil
+}
+
+// ParseStages parses stages like "2m:200,10m:200,30s:1000,1m:0", separated by comma or new line
+func ParseStages(profile string) ([]Stage, error) {
+	var stages []Stage
+
+	segs := strings.FieldsFunc(profile, func(r rune) bool {
+		return r == ',' || r == '\n'
+	})
+
+	for _, seg := range segs {
+		seg = strings.TrimSpace(seg)
+		if seg == "" {
+			continue
+		}
+
+		pair := strings.Split(seg, ":")
+		if len(pair) != 2 {
+			return nil, fmt.Errorf("stage <%s> must be <duration>:<target>", seg)
+		}
+
+		d, err := time.ParseDuration(pair[0])
+		if err != nil {
+			return nil, fmt.Errorf("stage <%s> duration error - %v", seg, err)
+		}
+
+		target, err := strconv.ParseFloat(pair[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("stage <%s> target must be a number", seg)
+		}
+
+		stage := Stage{Duration: d, Target: target}
+		if err = validateStage(&stage); err != nil {
+			return nil, err
+		}
+
+		stages = append(stages, stage)
+	}
+
+	return stages, nil
+}
+
+// LoadStages loads stages from a json file like [{"duration": "2m", "target": 200}],
+// other files are parsed as ParseStages does
+func LoadStages(filepath string) ([]Stage, error) {
+	data, err := ioutil.ReadFile(filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.HasSuffix(filepath, ".json") {
+		var stages []Stage
+		err = json.Unmarshal(data, &stages)
+		return stages, err
+	}
+
+	return ParseStages(string(data))
+}
+
+// StagesDuration returns the total duration of the stages
+func StagesDuration(stages []Stage) time.Duration {
+	var total time.Duration
+	for _, stage := range stages {
+		total += stage.Duration
+	}
+
+	return total
+}
+
+// TargetAt returns the target interpolated at elapsed, the target starts from 0
+func TargetAt(stages []Stage, elapsed time.Duration) float64 {
+	var from float64
+	for _, stage := range stages {
+		if elapsed < stage.Duration {
+			return from + (stage.Target-from)*float64(elapsed)/float64(stage.Duration)
+		}
+
+		elapsed -= stage.Duration
+		from = stage.Target
+	}
+
+	return from
+}
@@ -0,0 +1,114 @@
+package client
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseStages(t *testing.T) {
+	cases := []struct {
+		profile string
+		stages  []Stage
+	}{
+		{"2m:200", []Stage{{2 * time.Minute, 200}}},
+		{"2m:200,10m:200,30s:1000,1m:0", []Stage{{2 * time.Minute, 200}, {10 * time.Minute, 200}, {30 * time.Second, 1000}, {time.Minute, 0}}},
+		{" 1s:10 ,\n 500ms:2.5\n", []Stage{{time.Second, 10}, {500 * time.Millisecond, 2.5}}},
+		{"", nil},
+	}
+
+	for _, c := range cases {
+		stages, err := ParseStages(c.profile)
+		if err != nil {
+			t.Errorf("ParseStages(%q) failed - %v", c.profile, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(stages, c.stages) {
+			t.Errorf("ParseStages(%q) = %v, want %v", c.profile, stages, c.stages)
+		}
+	}
+
+	for _, profile := range []string{"2m", "2m:200:1", "two:200", "2m:many", "2m:-1", "0s:10", "-1m:10", "1m:NaN"} {
+		if _, err := ParseStages(profile); err == nil {
+			t.Errorf("ParseStages(%q) should fail", profile)
+		}
+	}
+}
+
+func TestLoadStages(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stages")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	expected := []Stage{{2 * time.Minute, 200}, {30 * time.Second, 0}}
+	files := map[string]string{
+		"stages.json": `[{"duration": "2m", "target": 200}, {"duration": "30s", "target": 0}]`,
+		"stages.txt":  "2m:200\n30s:0\n",
+	}
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err = ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		stages, err := LoadStages(path)
+		if err != nil {
+			t.Errorf("LoadStages(%s) failed - %v", name, err)
+		} else if !reflect.DeepEqual(stages, expected) {
+			t.Errorf("LoadStages(%s) = %v, want %v", name, stages, expected)
+		}
+	}
+
+	invalid := map[string]string{
+		"zero.json":     `[{"duration": "0s", "target": 200}]`,
+		"negative.json": `[{"duration": "1m", "target": -1}]`,
+		"negative.txt":  "-1m:200\n",
+	}
+
+	for name, content := range invalid {
+		path := filepath.Join(dir, name)
+		if err = ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err = LoadStages(path); err == nil {
+			t.Errorf("LoadStages(%s) should fail", name)
+		}
+	}
+}
+
+func TestTargetAt(t *testing.T) {
+	stages := []Stage{{10 * time.Second, 100}, {10 * time.Second, 100}, {5 * time.Second, 0}}
+
+	cases := []struct {
+		elapsed time.Duration
+		target  float64
+	}{
+		{0, 0},
+		{time.Second, 10},
+		{5 * time.Second, 50},
+		{10 * time.Second, 100},
+		{15 * time.Second, 100},
+		{20 * time.Second, 100},
+		{22500 * time.Millisecond, 50},
+		{25 * time.Second, 0},
+		{time.Minute, 0},
+	}
+
+	for _, c := range cases {
+		if target := TargetAt(stages, c.elapsed); target != c.target {
+			t.Errorf("TargetAt(%v) = %v, want %v", c.elapsed, target, c.target)
+		}
+	}
+
+	if d := StagesDuration(stages); d != 25*time.Second {
+		t.Errorf("StagesDuration = %v, want 25s", d)
+	}
+}
@@ -0,0 +1,123 @@
+package statistics
+
+import (
+	"math"
+	"math/bits"
+)
+
+const (
+	// values below 2^histogramSubBucketBits ns are exact, the others keep 2^histogramSubBucketBits buckets
+	// per power of 2 which is less than 1% relative error
+	histogramSubBucketBits  = 7
+	histogramSubBucketCount = 1 << histogramSubBucketBits
+	// values are capped at 2^histogramMaxBits ns, about 73 minutes
+	histogramMaxBits = 42
+	histogramSize    = (histogramMaxBits - histogramSubBucketBits + 1) * histogramSubBucketCount
+)
+
+// Histogram is an HDR style latency histogram in nanoseconds with fixed memory, histograms are mergeable
+type Histogram struct {
+	counts     [histogramSize]uint64
+	TotalCount uint64
+	Max        uint64
+	Min        uint64
+}
+
+func NewHistogram() *Histogram {
+	return new(Histogram)
+}
+
+func (h *Histogram) Record(value uint64) {
+	h.counts[histogramIndex(value)]++
+	h.TotalCount++
+
+	if h.Max == 0 || value > h.Max {
+		h.Max = value
+	}
+
+	if h.Min == 0 || value < h.Min {
+		h.Min = value
+	}
+}
+
+func (h *Histogram) Merge(other *Histogram) {
+	if other == nil || other.TotalCount == 0 {
+		return
+	}
+
+	for i, count := range other.counts {
+		h.counts[i] += count
+	}
+
+	h.TotalCount += other.TotalCount
+
+	if h.Max == 0 || other.Max > h.Max {
+		h.Max = other.Max
+	}
+
+	if h.Min == 0 || other.Min < h.Min {
+		h.Min = other.Min
+	}
+}
+
+// Percentile returns the value in nanoseconds that q percent (0-100) of the recorded values are less than or equal to
+func (h *Histogram) Percentile(q float64) uint64 {
+	if h.TotalCount == 0 {
+		return 0
+	}
+
+	if q >= 100 {
+		return h.Max
+	}
+
+	rank := uint64(math.Ceil(q / 100 * float64(h.TotalCount)))
+	if rank == 0 {
+		rank = 1
+	}
+
+	var sum uint64
+	for i, count := range h.counts {
+		sum += count
+		if sum >= rank {
+			return h.clamp(histogramValue(i))
+		}
+	}
+
+	return h.Max
+}
+
+func (h *Histogram) clamp(value uint64) uint64 {
+	if value > h.Max {
+		return h.Max
+	}
+
+	if value < h.Min {
+		return h.Min
+	}
+
+	return value
+}
+
+func histogramIndex(value uint64) int {
+	if value >= 1<<histogramMaxBits {
+		value = 1<<histogramMaxBits - 1
+	}
+
+	if value < histogramSubBucketCount {
+		return int(value)
+	}
+
+	shift := bits.Len64(value) - 1 - histogramSubBucketBits
+	return shift*histogramSubBucketCount + int(value>>uint(shift))
+}
+
+// histogramValue returns the middle value of the bucket
+func histogramValue(index int) uint64 {
+	if index < histogramSubBucketCount {
+		return uint64(index)
+	}
+
+	shift := uint(index/histogramSubBucketCount - 1)
+	lower := uint64(index-int(shift)*histogramSubBucketCount) << shift
+	return lower + (uint64(1)<<shift)>>1
+}
@@ -0,0 +1,112 @@
+package statistics
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestHistogramExactValues(t *testing.T) {
+	h := NewHistogram()
+	for v := uint64(1); v <= 100; v++ {
+		h.Record(v)
+	}
+
+	cases := []struct {
+		q        float64
+		expected uint64
+	}{
+		{0, 1},
+		{1, 1},
+		{50, 50},
+		{90, 90},
+		{99, 99},
+		{99.9, 100},
+		{100, 100},
+	}
+
+	for _, c := range cases {
+		if actual := h.Percentile(c.q); actual != c.expected {
+			t.Errorf("p%v = %d, want %d", c.q, actual, c.expected)
+		}
+	}
+
+	if h.Min != 1 || h.Max != 100 || h.TotalCount != 100 {
+		t.Errorf("min %d, max %d, total %d", h.Min, h.Max, h.TotalCount)
+	}
+}
+
+func TestHistogramAccuracy(t *testing.T) {
+	cases := []struct {
+		name     string
+		generate func(r *rand.Rand) uint64
+	}{
+		{"uniform 1ms-1s", func(r *rand.Rand) uint64 { return uint64(1e6 + r.Int63n(1e9)) }},
+		{"exponential 20ms", func(r *rand.Rand) uint64 { return uint64(r.ExpFloat64()*20e6) + 1 }},
+		{"lognormal", func(r *rand.Rand) uint64 { return uint64(math.Exp(r.NormFloat64()+16)) + 1 }},
+	}
+
+	for _, c := range cases {
+		r := rand.New(rand.NewSource(1))
+		h := NewHistogram()
+		values := make([]uint64, 100000)
+		for i := range values {
+			values[i] = c.generate(r)
+			h.Record(values[i])
+		}
+
+		sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+		for _, q := range []float64{50, 90, 95, 99, 99.9} {
+			exact := float64(values[int(math.Ceil(q/100*float64(len(values))))-1])
+			actual := float64(h.Percentile(q))
+			if relative := math.Abs(actual-exact) / exact; relative > 0.01 {
+				t.Errorf("%s p%v = %.0f, exact %.0f, error %.2f%% over 1%%", c.name, q, actual, exact, relative*100)
+			}
+		}
+
+		if h.Percentile(100) != values[len(values)-1] || h.Min != values[0] {
+			t.Errorf("%s min %d max %d, want %d %d", c.name, h.Min, h.Max, values[0], values[len(values)-1])
+		}
+	}
+}
+
+func TestHistogramMerge(t *testing.T) {
+	a, b, all := NewHistogram(), NewHistogram(), NewHistogram()
+	for v := uint64(1); v <= 1000; v++ {
+		if v%2 == 0 {
+			a.Record(v * 1000)
+		} else {
+			b.Record(v * 1000)
+		}
+
+		all.Record(v * 1000)
+	}
+
+	a.Merge(b)
+	a.Merge(nil)
+	a.Merge(NewHistogram())
+
+	if a.TotalCount != all.TotalCount || a.Min != all.Min || a.Max != all.Max {
+		t.Errorf("merged total %d min %d max %d, want %d %d %d", a.TotalCount, a.Min, a.Max, all.TotalCount, all.Min, all.Max)
+	}
+
+	for _, q := range []float64{50, 95, 99} {
+		if a.Percentile(q) != all.Percentile(q) {
+			t.Errorf("merged p%v = %d, want %d", q, a.Percentile(q), all.Percentile(q))
+		}
+	}
+}
+
+func TestHistogramEmptyAndCapped(t *testing.T) {
+	h := NewHistogram()
+	if h.Percentile(99) != 0 {
+		t.Errorf("p99 of empty histogram = %d", h.Percentile(99))
+	}
+
+	huge := uint64(1) << 50
+	h.Record(huge)
+	if h.Percentile(50) != huge || h.Max != huge {
+		t.Errorf("p50 of a value over the cap = %d, want the max %d", h.Percentile(50), huge)
+	}
+}
@@ -0,0 +1,127 @@
+package statistics
+
+import (
+	"sort"
+
+	"github.com/ginkgoch/stress-test/pkg/client/runner"
+)
+
+// Metrics are the counters of a group of task results, e.g. all results or the results of one category
+type Metrics struct {
+	SuccessNum,
+	FailureNum,
+	MaxTime,
+	MinTime,
+	ProcessTime,
+	DroppedNum,
+	LateNum uint64
+	Histogram *Histogram
+	Errors    map[string]*ErrorMetrics
+}
+
+// ErrorMetrics counts the failures of one error class, with the first error message as sample
+type ErrorMetrics struct {
+	Class  string `json:"class"`
+	Count  uint64 `json:"count"`
+	Sample string `json:"sample"`
+}
+
+func NewMetrics() *Metrics {
+	return &Metrics{Histogram: NewHistogram(), Errors: make(map[string]*ErrorMetrics)}
+}
+
+func (m *Metrics) Add(r *runner.TaskResult) {
+	if r.Dropped {
+		m.DroppedNum++
+		return
+	}
+
+	if r.Late {
+		m.LateNum++
+	}
+
+	m.ProcessTime += r.ProcessTime
+
+	if r.Success {
+		m.SuccessNum++
+	} else {
+		m.FailureNum++
+		m.addError(r)
+	}
+
+	if m.MaxTime == 0 || r.ProcessTime > m.MaxTime {
+		m.MaxTime = r.ProcessTime
+	}
+
+	if m.MinTime == 0 || r.ProcessTime < m.MinTime {
+		m.MinTime = r.ProcessTime
+	}
+
+	m.Histogram.Record(r.ProcessTime)
+}
+
+func (m *Metrics) addError(r *runner.TaskResult) {
+	class := r.ErrClass
+	if class == "" {
+		class = runner.ErrorClassOther
+	}
+
+	e, ok := m.Errors[class]
+	if !ok {
+		e = &ErrorMetrics{Class: class, Sample: r.Err}
+		m.Errors[class] = e
+	}
+
+	e.Count++
+}
+
+// TopErrors returns the error classes ordered by count
+func (m *Metrics) TopErrors() []*ErrorMetrics {
+	var errs []*ErrorMetrics
+	for _, e := range m.Errors {
+		errs = append(errs, e)
+	}
+
+	sort.Slice(errs, func(i, j int) bool {
+		if errs[i].Count != errs[j].Count {
+			return errs[i].Count > errs[j].Count
+		}
+
+		return errs[i].Class < errs[j].Class
+	})
+
+	return errs
+}
+
+func (m *Metrics) TotalNum() uint64 {
+	return m.SuccessNum + m.FailureNum
+}
+
+// Qps returns the succeeded task(s) per second
+func (m *Metrics) Qps(runningTime uint64) float64 {
+	return float64(m.SuccessNum*1e9) / float64(runningTime)
+}
+
+// Rate returns the finished task(s) per second
+func (m *Metrics) Rate(runningTime uint64) float64 {
+	return float64(m.TotalNum()*1e9) / float64(runningTime)
+}
+
+// AverageTime returns the average process time in ms
+func (m *Metrics) AverageTime() float64 {
+	return float64(m.ProcessTime) / 1e6 / float64(m.TotalNum())
+}
+
+// Percentile returns the process time in ms that q percent of the tasks are less than or equal to
+func (m *Metrics) Percentile(q float64) float64 {
+	return float64(m.Histogram.Percentile(q)) / 1e6
+}
+
+// ErrorRate returns the failed percentage
+func (m *Metrics) ErrorRate() float64 {
+	if m.TotalNum() == 0 {
+		return 0
+	}
+
+	return float64(m.FailureNum) * 100 / float64(m.TotalNum())
+}
@@ -0,0 +1,161 @@
+package statistics
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Reporter shows the progress and the result of a run, e.g. TableReporter
+type Reporter interface {
+	// Start is called before the first result
+	Start(st *ResultStatistics)
+	// Tick is called every second while the run is going
+	Tick(st *ResultStatistics)
+	// Finish is called after the last result
+	Finish(st *ResultStatistics)
+}
+
+const (
+	OutputFormatTable = "table"
+	OutputFormatPlain = "plain"
+	OutputFormatJSON  = "json"
+	OutputFormatQuiet = "quiet"
+
+	LocaleChinese = "zh"
+	LocaleEnglish = "en"
+)
+
+// NewReporter returns the reporter of format table, plain, json or quiet. The locale zh or en is the language
+// of the tables, empty means zh for table and en for plain
+func NewReporter(format string, locale string) (Reporter, error) {
+	switch locale {
+	case "", LocaleChinese, LocaleEnglish:
+	default:
+		return nil, fmt.Errorf("locale <%s> is not supported, use %s or %s", locale, LocaleChinese, LocaleEnglish)
+	}
+
+	switch format {
+	case "", OutputFormatTable:
+		return TableReporter{Locale: locale}, nil
+	case OutputFormatPlain:
+		if locale == "" {
+			locale = LocaleEnglish
+		}
+
+		return TableReporter{Locale: locale, Plain: true}, nil
+	case OutputFormatJSON:
+		return JSONReporter{}, nil
+	case OutputFormatQuiet:
+		return QuietReporter{}, nil
+	default:
+		return nil, fmt.Errorf("output format <%s> is not supported, use %s, %s, %s or %s", format, OutputFormatTable, OutputFormatPlain, OutputFormatJSON, OutputFormatQuiet)
+	}
+}
+
+// QuietReporter shows nothing
+type QuietReporter struct{}
+
+func (QuietReporter) Start(st *ResultStatistics) {}
+
+func (QuietReporter) Tick(st *ResultStatistics) {}
+
+func (QuietReporter) Finish(st *ResultStatistics) {}
+
+// JSONReporter writes a progress line of json every second, and the summary without timeline as the last line
+type JSONReporter struct {
+	// Out is the writer of the lines, default os.Stdout
+	Out io.Writer
+}
+
+// ProgressLine is the progress of the run written by JSONReporter, times are in ms
+type ProgressLine struct {
+	Type          string             `json:"type"`
+	ElapsedSec    float64            `json:"elapsedSec"`
+	Category      string             `json:"category,omitempty"`
+	SuccessNum    uint64             `json:"success"`
+	FailureNum    uint64             `json:"failure"`
+	DroppedNum    uint64             `json:"dropped,omitempty"`
+	LateNum       uint64             `json:"late,omitempty"`
+	Qps           float64            `json:"qps"`
+	Rate          float64            `json:"rate"`
+	TargetRate    float64            `json:"targetRate,omitempty"`
+	ConcurrentNum int                `json:"concurrency"`
+	MeanTime      float64            `json:"mean"`
+	Percentiles   map[string]float64 `json:"percentiles"`
+	// Window is the qps, mean and p99 of the time window
+	Window map[string]float64 `json:"window,omitempty"`
+}
+
+// SummaryLine is the last line written by JSONReporter
+type SummaryLine struct {
+	Type string `json:"type"`
+	*Summary
+}
+
+func (r JSONReporter) Start(st *ResultStatistics) {}
+
+func (r JSONReporter) Tick(st *ResultStatistics) {
+	r.write(st.progress())
+}
+
+func (r JSONReporter) Finish(st *ResultStatistics) {
+	summary := st.Summary()
+	summary.Timeline = nil
+	r.write(&SummaryLine{Type: "summary", Summary: summary})
+}
+
+func (r JSONReporter) write(line interface{}) {
+	out := r.Out
+	if out == nil {
+		out = os.Stdout
+	}
+
+	data, err := json.Marshal(line)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "write json line failed - %v\n", err)
+		return
+	}
+
+	out.Write(append(data, '\n'))
+}
+
+func (s *ResultStatistics) progress() *ProgressLine {
+	s.locker.RLock()
+	defer s.locker.RUnlock()
+
+	m := s.liveMetrics()
+	line := &ProgressLine{
+		Type:          "progress",
+		ElapsedSec:    float64(s.RunningTime) / 1e9,
+		Category:      LiveCategory,
+		SuccessNum:    m.SuccessNum,
+		FailureNum:    m.FailureNum,
+		DroppedNum:    m.DroppedNum,
+		LateNum:       m.LateNum,
+		TargetRate:    s.TargetRate,
+		ConcurrentNum: s.ConcurrentNum,
+		Percentiles:   make(map[string]float64),
+	}
+
+	if s.RunningTime > 0 {
+		line.Qps = m.Qps(s.RunningTime)
+		line.Rate = m.Rate(s.RunningTime)
+	}
+
+	if m.TotalNum() > 0 {
+		line.MeanTime = m.AverageTime()
+	}
+
+	for _, q := range SummaryPercentiles {
+		line.Percentiles[PercentileName(q)] = m.Percentile(q)
+	}
+
+	if s.TimeWindow != nil {
+		qps, mean := s.TimeWindow.Info()
+		line.Window = map[string]float64{"qps": qps, "mean": mean, "p99": s.TimeWindow.Percentile(99)}
+	}
+
+	return line
+}
@@ -0,0 +1,19 @@
+package statistics
+
+import (
+	"github.com/ginkgoch/stress-test/pkg/client/runner"
+)
+
+// ResultSink receives every watched result, e.g. to store the raw results or to export metrics.
+// Write is always called from the watching goroutine, Close is called once the run is finished.
+// Only the results of Watch reach the sinks, the results appended outside a run, e.g. replayed by AppendRecorded
+// in the report command, do not.
+type ResultSink interface {
+	Write(r *runner.TaskResult) error
+	Close() error
+}
+
+// StatisticsAware sinks are told the statistics they are added to, e.g. to export the live gauges
+type StatisticsAware interface {
+	SetStatistics(st *ResultStatistics)
+}
@@ -2,6 +2,8 @@ package statistics
 
 import (
 	"fmt"
+	"os"
+	"sort"
 	"sync"
 	"time"
 
@@ -11,21 +13,35 @@ import (
 
 var (
 	TimeWindowSizeInSec int
+	// LiveCategory shows the live table of one category instead of all results when it is set
+	LiveCategory string
 )
 
 type ResultStatistics struct {
 	ConcurrentNum int
+	// TargetRate is the task(s) per second limitation, 0 means unlimited
+	TargetRate float64
+	// OpenModel shows the dropped and late tasks of the arrival rate executor
+	OpenModel bool
+	// Staged shows the current target of the load profile
+	Staged bool
 	StartTime,
-	SuccessNum,
-	FailureNum,
-	MaxTime,
-	MinTime,
-	RunningTime,
-	ProcessTime uint64
+	RunningTime uint64
+	Metrics
+	Categories map[string]*Metrics
+	// Scenarios are the metrics of every scenario of a mixed run
+	Scenarios  map[string]*Metrics
+	Timeline   *Timeline
 	TimeWindow *TimeWindow
-	locker     sync.RWMutex
+	Sinks      []ResultSink
+	// Reporters show the run, nil means TableReporter
+	Reporters []Reporter
+	locker    sync.RWMutex
 }
 
+// SummaryPercentiles are the percentiles printed in the summary
+var SummaryPercentiles = []float64{50, 90, 95, 99, 99.9}
+
 func NewResultStatistics(concurrentNum int) *ResultStatistics {
 	log.InitLogger()
 
@@ -41,12 +57,11 @@ func NewResultStatistics(concurrentNum int) *ResultStatistics {
 	return &ResultStatistics{
 		ConcurrentNum: concurrentNum,
 		StartTime:     0,
-		SuccessNum:    0,
-		FailureNum:    0,
-		MaxTime:       0,
-		MinTime:       0,
 		RunningTime:   0,
-		ProcessTime:   0,
+		Metrics:       *NewMetrics(),
+		Categories:    make(map[string]*Metrics),
+		Scenarios:     make(map[string]*Metrics),
+		Timeline:      NewTimeline(),
 		TimeWindow:    timeWindow,
 	}
 }
@@ -56,99 +71,213 @@ func (s *ResultStatistics) Watch(ch <-chan *runner.TaskResult, wg *sync.WaitGrou
 
 	s.StartTime = uint64(time.Now().UnixNano())
 
+	reporters := s.Reporters
+	if reporters == nil {
+		reporters = []Reporter{TableReporter{}}
+	}
+
+	// the ticks run in one goroutine, which is done before Finish so no tick follows the summary
 	stopCh := make(chan bool)
+	tickDone := make(chan struct{})
 	ticker := time.NewTicker(time.Second)
 	go func() {
+		defer close(tickDone)
 		for {
 			select {
 			case <-ticker.C:
-				go s.PrintTableRow()
+				for _, reporter := range reporters {
+					reporter.Tick(s)
+				}
 			case <-stopCh:
 				return
 			}
 		}
 	}()
 
-	s.PrintTableHeader()
+	for _, reporter := range reporters {
+		reporter.Start(s)
+	}
+
 	for r := range ch {
 		s.Append(r)
+		s.writeSinks(r)
 		log.Println(r)
 	}
 
-	stopCh <- true
-	s.PrintTableRow()
+	close(stopCh)
+	ticker.Stop()
+	<-tickDone
+	s.closeSinks()
+	s.Timeline.Seal()
+
+	for _, reporter := range reporters {
+		reporter.Finish(s)
+	}
 }
 
 func (s *ResultStatistics) Append(r *runner.TaskResult) {
 	s.locker.Lock()
 	defer s.locker.Unlock()
 
-	s.ProcessTime += r.ProcessTime
 	s.RunningTime = uint64(time.Now().UnixNano()) - s.StartTime
+	s.append(r)
+}
+
+// AppendRecorded appends a result recorded before, e.g. from a raw result file,
+// the running time follows the time of the results instead of the clock
+func (s *ResultStatistics) AppendRecorded(r *runner.TaskResult) {
+	s.locker.Lock()
+	defer s.locker.Unlock()
 
-	if r.Success {
-		s.SuccessNum++
-	} else {
-		s.FailureNum++
+	endTime := s.StartTime + s.RunningTime
+	if s.StartTime == 0 || r.StartTime < s.StartTime {
+		s.StartTime = r.StartTime
 	}
 
-	if s.MaxTime == 0 || r.ProcessTime > s.MaxTime {
-		s.MaxTime = r.ProcessTime
+	if r.EndTime > endTime {
+		endTime = r.EndTime
+	}
+
+	s.RunningTime = endTime - s.StartTime
+	s.append(r)
+}
+
+func (s *ResultStatistics) append(r *runner.TaskResult) {
+	s.Metrics.Add(r)
+	s.Timeline.Append(r)
+
+	category, ok := s.Categories[r.Category]
+	if !ok {
+		category = NewMetrics()
+		s.Categories[r.Category] = category
 	}
+	category.Add(r)
 
-	if s.MinTime == 0 || r.ProcessTime < s.MinTime {
-		s.MinTime = r.ProcessTime
+	if r.Scenario != "" {
+		scenario, ok := s.Scenarios[r.Scenario]
+		if !ok {
+			scenario = NewMetrics()
+			s.Scenarios[r.Scenario] = scenario
+		}
+		scenario.Add(r)
 	}
 
-	if s.TimeWindow != nil {
+	if s.TimeWindow != nil && !r.Dropped && (LiveCategory == "" || LiveCategory == r.Category || LiveCategory == r.Scenario) {
 		s.TimeWindow.Append(r)
 	}
 }
 
-func (s *ResultStatistics) PrintTableHeader() {
-	lineTop := "─────────┬─────────┬─────────┬──────────┬──────────┬──────────┬──────────"
-	lineBtm := "─────────┼─────────┼─────────┼──────────┼──────────┼──────────┼──────────"
-	headMid := " 耗时(s) │  成功数 │  失败数 │     qps  │ 最长耗时 │ 最短耗时 │ 平均耗时 "
-	if s.TimeWindow != nil {
-		lineTop += "┬──────────┬──────────"
-		lineBtm += "┼──────────┼──────────"
-		headMid += "│   qps-w  │平均耗时-w"
+func (s *ResultStatistics) AddSink(sink ResultSink) {
+	if aware, ok := sink.(StatisticsAware); ok {
+		aware.SetStatistics(s)
+	}
+
+	s.Sinks = append(s.Sinks, sink)
+}
+
+// Gauges returns the live target concurrency, the target rate and the achieved rate of the last second
+func (s *ResultStatistics) Gauges() (concurrentNum int, targetRate float64, rate float64) {
+	s.locker.RLock()
+	defer s.locker.RUnlock()
+
+	lastSecond := time.Now().Unix() - 1
+	points := s.Timeline.Points
+	for i := len(points) - 1; i >= 0 && points[i].Second >= lastSecond; i-- {
+		if points[i].Second == lastSecond {
+			rate = float64(points[i].TotalNum())
+		}
 	}
 
-	fmt.Println(lineTop)
-	fmt.Println(headMid)
-	fmt.Println(lineBtm)
+	return s.ConcurrentNum, s.TargetRate, rate
 }
 
-func (s *ResultStatistics) PrintTableRow() {
+// View calls view with the metrics of category under the read lock, empty category means all results,
+// a name of no category is looked up in the scenarios. It returns false when the category has no results
+func (s *ResultStatistics) View(category string, view func(m *Metrics, runningTime uint64)) bool {
 	s.locker.RLock()
 	defer s.locker.RUnlock()
 
-	processTime := s.ProcessTime
-	if processTime == 0 {
-		processTime = 1
+	m := &s.Metrics
+	if category != "" {
+		var ok bool
+		if m, ok = s.Categories[category]; !ok {
+			if m, ok = s.Scenarios[category]; !ok {
+				return false
+			}
+		}
+	}
+
+	view(m, s.RunningTime)
+	return true
+}
+
+// writeSinks writes r to the sinks, a failed sink is reported and removed
+func (s *ResultStatistics) writeSinks(r *runner.TaskResult) {
+	for i := 0; i < len(s.Sinks); i++ {
+		if err := s.Sinks[i].Write(r); err != nil {
+			fmt.Fprintf(os.Stderr, "result sink failed - %v\n", err)
+			s.Sinks[i].Close()
+			s.Sinks = append(s.Sinks[:i], s.Sinks[i+1:]...)
+			i--
+		}
+	}
+}
+
+func (s *ResultStatistics) closeSinks() {
+	for _, sink := range s.Sinks {
+		if err := sink.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "close result sink failed - %v\n", err)
+		}
+	}
+}
+
+// CategoryNames returns the sorted category names
+func (s *ResultStatistics) CategoryNames() []string {
+	return sortedNames(s.Categories)
+}
+
+// ScenarioNames returns the sorted scenario names
+func (s *ResultStatistics) ScenarioNames() []string {
+	return sortedNames(s.Scenarios)
+}
+
+func sortedNames(groups map[string]*Metrics) []string {
+	var names []string
+	for name := range groups {
+		names = append(names, name)
 	}
 
-	var realtimeQps, realtimeSpeed float64
-	if s.TimeWindow != nil {
-		realtimeQps, realtimeSpeed = s.TimeWindow.Info()
+	sort.Strings(names)
+	return names
+}
+
+// liveMetrics returns the metrics of LiveCategory, which is a category or a scenario, or the metrics of all results
+func (s *ResultStatistics) liveMetrics() *Metrics {
+	if LiveCategory == "" {
+		return &s.Metrics
 	}
 
-	row := fmt.Sprintf(" %7d │ %7d │ %7d │ %8.2f │ %8.2f │ %8.2f │ %8.2f ",
-		s.RunningTime/1e9,
-		s.SuccessNum,
-		s.FailureNum,
-		// qps can also be more precise when no rate limiter involved
-		// float64(s.SuccessNum*uint64(s.ConcurrentNum)*1e9) / float64(processTime)
-		float64(s.SuccessNum*1e9)/float64(s.RunningTime),
-		float64(s.MaxTime)/1e6,
-		float64(s.MinTime)/1e6,
-		float64(s.ProcessTime)/1e6/float64(s.SuccessNum+s.FailureNum),
-	)
+	if category, ok := s.Categories[LiveCategory]; ok {
+		return category
+	}
 
-	if s.TimeWindow != nil {
-		row = fmt.Sprintf("%s│ %8.2f │ %8.2f ", row, realtimeQps, realtimeSpeed)
+	if scenario, ok := s.Scenarios[LiveCategory]; ok {
+		return scenario
 	}
 
-	fmt.Println(row)
+	return NewMetrics()
+}
+
+func (s *ResultStatistics) SetConcurrentNum(concurrentNum int) {
+	s.locker.Lock()
+	defer s.locker.Unlock()
+
+	s.ConcurrentNum = concurrentNum
+}
+
+func (s *ResultStatistics) SetTargetRate(rate float64) {
+	s.locker.Lock()
+	defer s.locker.Unlock()
+
+	s.TargetRate = rate
 }
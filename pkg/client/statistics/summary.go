@@ -0,0 +1,113 @@
+package statistics
+
+import (
+	"fmt"
+	"time"
+)
+
+// Summary is the machine readable result of a run
+type Summary struct {
+	Config      *RunConfig        `json:"config,omitempty"`
+	StartTime   time.Time         `json:"startTime"`
+	EndTime     time.Time         `json:"endTime"`
+	DurationSec float64           `json:"durationSec"`
+	Totals      *MetricsSummary   `json:"totals"`
+	Categories  []*MetricsSummary `json:"categories"`
+	Scenarios   []*MetricsSummary `json:"scenarios,omitempty"`
+	Errors      []*ErrorMetrics   `json:"errors"`
+	Timeline    []*TimelinePoint  `json:"timeline,omitempty"`
+}
+
+// RunConfig describes how the run is started
+type RunConfig struct {
+	Command string            `json:"command"`
+	Args    []string          `json:"args,omitempty"`
+	Target  string            `json:"target,omitempty"`
+	Flags   map[string]string `json:"flags,omitempty"`
+}
+
+// MetricsSummary is the summary of all results or the results of one category, times are in ms
+type MetricsSummary struct {
+	Name        string             `json:"name"`
+	TotalNum    uint64             `json:"total"`
+	SuccessNum  uint64             `json:"success"`
+	FailureNum  uint64             `json:"failure"`
+	DroppedNum  uint64             `json:"dropped,omitempty"`
+	LateNum     uint64             `json:"late,omitempty"`
+	ErrorRate   float64            `json:"errorRate"`
+	Qps         float64            `json:"qps"`
+	Rate        float64            `json:"rate"`
+	TargetRate  float64            `json:"targetRate,omitempty"`
+	MinTime     float64            `json:"min"`
+	MeanTime    float64            `json:"mean"`
+	MaxTime     float64            `json:"max"`
+	Percentiles map[string]float64 `json:"percentiles"`
+	Errors      []*ErrorMetrics    `json:"errors,omitempty"`
+}
+
+// PercentileName returns the name of percentile q, e.g. p99.9
+func PercentileName(q float64) string {
+	return fmt.Sprintf("p%v", q)
+}
+
+// Summary returns the summary of the results watched so far
+func (s *ResultStatistics) Summary() *Summary {
+	s.locker.RLock()
+	defer s.locker.RUnlock()
+
+	startTime := time.Unix(0, int64(s.StartTime))
+	summary := &Summary{
+		StartTime:   startTime,
+		EndTime:     startTime.Add(time.Duration(s.RunningTime)),
+		DurationSec: float64(s.RunningTime) / 1e9,
+		Totals:      s.Metrics.summary("total", s.RunningTime),
+		Errors:      s.TopErrors(),
+		Timeline:    s.Timeline.Points,
+	}
+
+	summary.Totals.TargetRate = s.TargetRate
+
+	for _, name := range s.CategoryNames() {
+		category := s.Categories[name].summary(name, s.RunningTime)
+		category.Errors = s.Categories[name].TopErrors()
+		summary.Categories = append(summary.Categories, category)
+	}
+
+	for _, name := range s.ScenarioNames() {
+		scenario := s.Scenarios[name].summary(name, s.RunningTime)
+		scenario.Errors = s.Scenarios[name].TopErrors()
+		summary.Scenarios = append(summary.Scenarios, scenario)
+	}
+
+	return summary
+}
+
+func (m *Metrics) summary(name string, runningTime uint64) *MetricsSummary {
+	summary := &MetricsSummary{
+		Name:        name,
+		TotalNum:    m.TotalNum(),
+		SuccessNum:  m.SuccessNum,
+		FailureNum:  m.FailureNum,
+		DroppedNum:  m.DroppedNum,
+		LateNum:     m.LateNum,
+		ErrorRate:   m.ErrorRate(),
+		MinTime:     float64(m.MinTime) / 1e6,
+		MaxTime:     float64(m.MaxTime) / 1e6,
+		Percentiles: make(map[string]float64),
+	}
+
+	if runningTime > 0 {
+		summary.Qps = m.Qps(runningTime)
+		summary.Rate = m.Rate(runningTime)
+	}
+
+	if m.TotalNum() > 0 {
+		summary.MeanTime = m.AverageTime()
+	}
+
+	for _, q := range SummaryPercentiles {
+		summary.Percentiles[PercentileName(q)] = m.Percentile(q)
+	}
+
+	return summary
+}
@@ -0,0 +1,391 @@
+package statistics
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// TableReporter prints the live table and the summary tables to the terminal
+type TableReporter struct {
+	// Locale is the language of the headers, zh or en, default zh
+	Locale string
+	// Plain draws the tables in ascii instead of box-drawing characters, e.g. for ci logs
+	Plain bool
+	// Out is the writer of the tables, default os.Stdout
+	Out io.Writer
+}
+
+func (t TableReporter) Start(st *ResultStatistics) {
+	t.PrintTableHeader(st)
+}
+
+func (t TableReporter) Tick(st *ResultStatistics) {
+	t.PrintTableRow(st)
+}
+
+func (t TableReporter) Finish(st *ResultStatistics) {
+	t.PrintTableRow(st)
+	t.PrintSummary(st)
+	t.PrintCategories(st)
+	t.PrintScenarios(st)
+	t.PrintErrors(st)
+}
+
+// Column is a column of the tables, the title is aligned right unless Left is set
+type Column struct {
+	Title string
+	Width int
+	Left  bool
+}
+
+func rightColumn(title string, width int) Column {
+	return Column{Title: title, Width: width}
+}
+
+func leftColumn(title string, width int) Column {
+	return Column{Title: title, Width: width, Left: true}
+}
+
+var plainReplacer = strings.NewReplacer("─", "-", "┬", "+", "┼", "+", "│", "|", "✗", "x", "↑", "^")
+
+// Label returns zh, or en of the english locale
+func (t TableReporter) Label(zh string, en string) string {
+	if t.Locale == LocaleEnglish {
+		return en
+	}
+
+	return zh
+}
+
+// Println prints a line of the tables, in ascii when Plain is set
+func (t TableReporter) Println(line string) {
+	if t.Plain {
+		line = plainReplacer.Replace(line)
+	}
+
+	out := t.Out
+	if out == nil {
+		out = os.Stdout
+	}
+
+	fmt.Fprintln(out, line)
+}
+
+// PrintHeader prints the titles of the columns between the lines
+func (t TableReporter) PrintHeader(columns []Column) {
+	var lines, titles []string
+	for _, c := range columns {
+		lines = append(lines, strings.Repeat("─", c.Width))
+		if displayWidth(c.Title) >= c.Width-1 {
+			titles = append(titles, padLeft(c.Title, c.Width))
+		} else if c.Left {
+			titles = append(titles, " "+padRight(c.Title, c.Width-1))
+		} else {
+			titles = append(titles, padLeft(c.Title, c.Width-1)+" ")
+		}
+	}
+
+	t.Println(strings.Join(lines, "┬"))
+	t.Println(strings.Join(titles, "│"))
+	t.Println(strings.Join(lines, "┼"))
+}
+
+// displayWidth returns the terminal columns of text, a han character takes 2 columns
+func displayWidth(text string) int {
+	width := 0
+	for _, r := range text {
+		if unicode.Is(unicode.Han, r) {
+			width += 2
+		} else {
+			width++
+		}
+	}
+
+	return width
+}
+
+func padLeft(text string, width int) string {
+	if n := width - displayWidth(text); n > 0 {
+		return strings.Repeat(" ", n) + text
+	}
+
+	return text
+}
+
+func padRight(text string, width int) string {
+	if n := width - displayWidth(text); n > 0 {
+		return text + strings.Repeat(" ", n)
+	}
+
+	return text
+}
+
+func (t TableReporter) PrintTableHeader(s *ResultStatistics) {
+	columns := []Column{
+		rightColumn(t.Label("耗时(s)", "time(s)"), 9),
+		rightColumn(t.Label("成功数", "success"), 9),
+		rightColumn(t.Label("失败数", "failure"), 9),
+		rightColumn("qps", 10),
+		rightColumn(t.Label("最长耗时", "max"), 10),
+		rightColumn(t.Label("最短耗时", "min"), 10),
+		rightColumn(t.Label("平均耗时", "mean"), 10),
+		rightColumn("p50", 10),
+		rightColumn("p95", 10),
+		rightColumn("p99", 10),
+	}
+
+	if s.Staged && !s.OpenModel {
+		columns = append(columns, rightColumn(t.Label("目标并发", "threads"), 10))
+	}
+	if s.TargetRate > 0 || s.OpenModel {
+		columns = append(columns, rightColumn(t.Label("速率", "rate"), 10), rightColumn(t.Label("目标速率", "target"), 10))
+	}
+	if s.OpenModel {
+		columns = append(columns, rightColumn(t.Label("丢弃数", "dropped"), 9), rightColumn(t.Label("延迟数", "late"), 9))
+	}
+	if s.TimeWindow != nil {
+		columns = append(columns, rightColumn("qps-w", 10), rightColumn(t.Label("平均耗时-w", "mean-w"), 10), rightColumn("p99-w", 10))
+	}
+
+	if LiveCategory != "" {
+		t.Println(fmt.Sprintf("%s: %s", t.Label("类别", "category"), LiveCategory))
+	}
+
+	t.PrintHeader(columns)
+}
+
+func (t TableReporter) PrintTableRow(s *ResultStatistics) {
+	s.locker.RLock()
+	defer s.locker.RUnlock()
+
+	var realtimeQps, realtimeSpeed, realtimeP99 float64
+	if s.TimeWindow != nil {
+		realtimeQps, realtimeSpeed = s.TimeWindow.Info()
+		realtimeP99 = s.TimeWindow.Percentile(99)
+	}
+
+	m := s.liveMetrics()
+	row := fmt.Sprintf(" %7d │ %7d │ %7d │ %8.2f │ %8.2f │ %8.2f │ %8.2f │ %8.2f │ %8.2f │ %8.2f ",
+		s.RunningTime/1e9,
+		m.SuccessNum,
+		m.FailureNum,
+		// qps can also be more precise when no rate limiter involved
+		// float64(s.SuccessNum*uint64(s.ConcurrentNum)*1e9) / float64(processTime)
+		m.Qps(s.RunningTime),
+		float64(m.MaxTime)/1e6,
+		float64(m.MinTime)/1e6,
+		m.AverageTime(),
+		m.Percentile(50),
+		m.Percentile(95),
+		m.Percentile(99),
+	)
+
+	if s.Staged && !s.OpenModel {
+		row = fmt.Sprintf("%s│ %8d ", row, s.ConcurrentNum)
+	}
+
+	if s.TargetRate > 0 || s.OpenModel {
+		row = fmt.Sprintf("%s│ %8.2f │ %8.2f ", row, m.Rate(s.RunningTime), s.TargetRate)
+	}
+
+	if s.OpenModel {
+		row = fmt.Sprintf("%s│ %7d │ %7d ", row, m.DroppedNum, m.LateNum)
+	}
+
+	if s.TimeWindow != nil {
+		row = fmt.Sprintf("%s│ %8.2f │ %8.2f │ %8.2f ", row, realtimeQps, realtimeSpeed, realtimeP99)
+	}
+
+	t.Println(row)
+}
+
+// PrintSummary prints the percentiles of the process time in ms
+func (t TableReporter) PrintSummary(s *ResultStatistics) {
+	s.locker.RLock()
+	defer s.locker.RUnlock()
+
+	var columns []Column
+	var row []string
+	for _, q := range SummaryPercentiles {
+		columns = append(columns, rightColumn(PercentileName(q), 10))
+		row = append(row, fmt.Sprintf(" %8.2f ", s.Percentile(q)))
+	}
+
+	t.Println("")
+	t.Println(t.Label("耗时百分位(ms)", "latency percentiles (ms)"))
+	t.PrintHeader(columns)
+	t.Println(strings.Join(row, "│"))
+}
+
+// PrintCategories prints the statistics of every category
+func (t TableReporter) PrintCategories(s *ResultStatistics) {
+	s.locker.RLock()
+	defer s.locker.RUnlock()
+
+	t.printGroups(s, t.Label("类别", "category"), s.Categories)
+}
+
+// PrintScenarios prints the statistics of every scenario of a mixed run, nothing is printed without scenarios
+func (t TableReporter) PrintScenarios(s *ResultStatistics) {
+	s.locker.RLock()
+	defer s.locker.RUnlock()
+
+	if len(s.Scenarios) == 0 {
+		return
+	}
+
+	t.printGroups(s, t.Label("场景", "scenario"), s.Scenarios)
+}
+
+func (t TableReporter) printGroups(s *ResultStatistics, title string, groups map[string]*Metrics) {
+	names := sortedNames(groups)
+
+	width := 8
+	for _, name := range names {
+		if len(name) > width {
+			width = len(name)
+		}
+	}
+
+	t.Println("")
+	t.PrintHeader([]Column{
+		leftColumn(title, width+2),
+		rightColumn(t.Label("成功数", "success"), 9),
+		rightColumn(t.Label("失败数", "failure"), 9),
+		rightColumn("qps", 10),
+		rightColumn(t.Label("失败率", "errors"), 10),
+		rightColumn(t.Label("平均耗时", "mean"), 10),
+		rightColumn("p50", 10),
+		rightColumn("p90", 10),
+		rightColumn("p95", 10),
+		rightColumn("p99", 10),
+		rightColumn(t.Label("最长耗时", "max"), 10),
+	})
+
+	for _, name := range names {
+		m := groups[name]
+		t.Println(fmt.Sprintf(" %-*s │ %7d │ %7d │ %8.2f │ %7.2f%% │ %8.2f │ %8.2f │ %8.2f │ %8.2f │ %8.2f │ %8.2f ",
+			width,
+			name,
+			m.SuccessNum,
+			m.FailureNum,
+			m.Qps(s.RunningTime),
+			m.ErrorRate(),
+			m.AverageTime(),
+			m.Percentile(50),
+			m.Percentile(90),
+			m.Percentile(95),
+			m.Percentile(99),
+			float64(m.MaxTime)/1e6,
+		))
+	}
+}
+
+// PrintErrors prints the failures grouped by error class, with the first error message of each class
+func (t TableReporter) PrintErrors(s *ResultStatistics) {
+	s.locker.RLock()
+	defer s.locker.RUnlock()
+
+	errs := s.TopErrors()
+	if len(errs) == 0 {
+		return
+	}
+
+	width := 20
+	for _, e := range errs {
+		if len(e.Class) > width {
+			width = len(e.Class)
+		}
+	}
+
+	t.Println("")
+	t.PrintHeader([]Column{
+		leftColumn(t.Label("错误类型", "error class"), width+2),
+		rightColumn(t.Label("次数", "count"), 9),
+		rightColumn(t.Label("占比", "share"), 10),
+		leftColumn(t.Label("示例", "sample"), maxSampleLength+1),
+	})
+
+	for _, e := range errs {
+		t.Println(fmt.Sprintf(" %-*s │ %7d │ %7.2f%% │ %s", width, e.Class, e.Count, float64(e.Count)*100/float64(s.FailureNum), truncate(e.Sample, maxSampleLength)))
+	}
+}
+
+const maxSampleLength = 60
+
+func truncate(str string, length int) string {
+	runes := []rune(str)
+	if len(runes) <= length {
+		return str
+	}
+
+	return string(runes[:length-3]) + "..."
+}
+
+// PrintTimeline prints the metrics of every second
+func (t TableReporter) PrintTimeline(s *ResultStatistics) {
+	s.locker.RLock()
+	defer s.locker.RUnlock()
+
+	t.PrintHeader([]Column{
+		rightColumn(t.Label("耗时(s)", "time(s)"), 9),
+		rightColumn(t.Label("成功数", "success"), 9),
+		rightColumn(t.Label("失败数", "failure"), 9),
+		rightColumn(t.Label("速率", "rate"), 10),
+		rightColumn(t.Label("最长耗时", "max"), 10),
+		rightColumn(t.Label("平均耗时", "mean"), 10),
+		rightColumn("p50", 10),
+		rightColumn("p95", 10),
+		rightColumn("p99", 10),
+	})
+
+	for _, point := range s.Timeline.Points {
+		t.Println(fmt.Sprintf(" %7d │ %7d │ %7d │ %8.2f │ %8.2f │ %8.2f │ %8.2f │ %8.2f │ %8.2f ",
+			point.Second-int64(s.StartTime/1e9),
+			point.SuccessNum,
+			point.FailureNum,
+			point.Rate(s.StartTime, s.StartTime+s.RunningTime),
+			point.MaxTime,
+			point.MeanTime,
+			point.Percentiles[PercentileName(50)],
+			point.Percentiles[PercentileName(95)],
+			point.Percentiles[PercentileName(99)],
+		))
+	}
+}
+
+func (s *ResultStatistics) PrintTableHeader() {
+	TableReporter{}.PrintTableHeader(s)
+}
+
+func (s *ResultStatistics) PrintTableRow() {
+	TableReporter{}.PrintTableRow(s)
+}
+
+// PrintSummary prints the percentiles of the process time in ms
+func (s *ResultStatistics) PrintSummary() {
+	TableReporter{}.PrintSummary(s)
+}
+
+// PrintCategories prints the statistics of every category
+func (s *ResultStatistics) PrintCategories() {
+	TableReporter{}.PrintCategories(s)
+}
+
+// PrintScenarios prints the statistics of every scenario of a mixed run
+func (s *ResultStatistics) PrintScenarios() {
+	TableReporter{}.PrintScenarios(s)
+}
+
+// PrintErrors prints the failures grouped by error class
+func (s *ResultStatistics) PrintErrors() {
+	TableReporter{}.PrintErrors(s)
+}
+
+// PrintTimeline prints the metrics of every second
+func (s *ResultStatistics) PrintTimeline() {
+	TableReporter{}.PrintTimeline(s)
+}
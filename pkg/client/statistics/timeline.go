@@ -0,0 +1,144 @@
+package statistics
+
+import (
+	"github.com/ginkgoch/stress-test/pkg/client/runner"
+)
+
+const (
+	// the latest seconds keep their histograms for the results arriving out of order,
+	// older seconds only keep the percentiles
+	timelineOpenSeconds = 5
+)
+
+// Timeline keeps the metrics of every second by the end time of the results
+type Timeline struct {
+	Points []*TimelinePoint
+}
+
+// TimelinePoint is the metrics of one second, times are in ms
+type TimelinePoint struct {
+	Second      int64              `json:"second"`
+	SuccessNum  uint64             `json:"success"`
+	FailureNum  uint64             `json:"failure"`
+	DroppedNum  uint64             `json:"dropped,omitempty"`
+	ProcessTime uint64             `json:"-"`
+	MeanTime    float64            `json:"mean"`
+	MaxTime     float64            `json:"max"`
+	Percentiles map[string]float64 `json:"percentiles"`
+	histogram   *Histogram
+}
+
+func NewTimeline() *Timeline {
+	return new(Timeline)
+}
+
+func (t *Timeline) Append(r *runner.TaskResult) {
+	timestamp := r.EndTime
+	if r.Dropped {
+		timestamp = r.StartTime
+	}
+
+	point := t.point(int64(timestamp / 1e9))
+	if r.Dropped {
+		point.DroppedNum++
+		return
+	}
+
+	if r.Success {
+		point.SuccessNum++
+	} else {
+		point.FailureNum++
+	}
+
+	point.ProcessTime += r.ProcessTime
+	if processTime := float64(r.ProcessTime) / 1e6; processTime > point.MaxTime {
+		point.MaxTime = processTime
+	}
+
+	if point.histogram != nil {
+		point.histogram.Record(r.ProcessTime)
+	}
+}
+
+// point returns the point of second, the points are mostly appended in order
+func (t *Timeline) point(second int64) *TimelinePoint {
+	i := len(t.Points)
+	for i > 0 && t.Points[i-1].Second > second {
+		i--
+	}
+
+	if i > 0 && t.Points[i-1].Second == second {
+		return t.Points[i-1]
+	}
+
+	point := &TimelinePoint{Second: second, histogram: NewHistogram()}
+	t.Points = append(t.Points, nil)
+	copy(t.Points[i+1:], t.Points[i:])
+	t.Points[i] = point
+
+	t.seal(t.Points[len(t.Points)-1].Second - timelineOpenSeconds)
+	return point
+}
+
+// seal keeps the percentiles of the points before second and releases their histograms
+func (t *Timeline) seal(second int64) {
+	for _, point := range t.Points {
+		if point.Second >= second {
+			break
+		}
+
+		point.seal()
+	}
+}
+
+// Seal keeps the percentiles of all points, call it when no more results are coming
+func (t *Timeline) Seal() {
+	for _, point := range t.Points {
+		point.seal()
+	}
+}
+
+// Rate returns the finished task(s) per second of the point, its second is clipped to the run from
+// startTime to endTime in ns, e.g. the first and the last second of a run are partial
+func (p *TimelinePoint) Rate(startTime uint64, endTime uint64) float64 {
+	from, to := uint64(p.Second)*1e9, uint64(p.Second+1)*1e9
+	if startTime > from {
+		from = startTime
+	}
+
+	if endTime > from && endTime < to {
+		to = endTime
+	}
+
+	return float64((p.SuccessNum+p.FailureNum)*1e9) / float64(to-from)
+}
+
+func (p *TimelinePoint) seal() {
+	if p.histogram == nil {
+		return
+	}
+
+	p.Percentiles = make(map[string]float64)
+	for _, q := range SummaryPercentiles {
+		p.Percentiles[PercentileName(q)] = float64(p.histogram.Percentile(q)) / 1e6
+	}
+
+	if total := p.TotalNum(); total > 0 {
+		p.MeanTime = float64(p.ProcessTime) / 1e6 / float64(total)
+	}
+
+	p.histogram = nil
+}
+
+func (p *TimelinePoint) TotalNum() uint64 {
+	return p.SuccessNum + p.FailureNum
+}
+
+// ErrorRate returns the failed percentage of the second
+func (p *TimelinePoint) ErrorRate() float64 {
+	if p.TotalNum() == 0 {
+		return 0
+	}
+
+	return float64(p.FailureNum) * 100 / float64(p.TotalNum())
+}
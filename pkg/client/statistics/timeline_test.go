@@ -0,0 +1,64 @@
+package statistics
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/ginkgoch/stress-test/pkg/client/runner"
+)
+
+func TestTimelinePointRate(t *testing.T) {
+	cases := []struct {
+		name      string
+		point     TimelinePoint
+		startTime uint64
+		endTime   uint64
+		rate      float64
+	}{
+		{"full second", TimelinePoint{Second: 10, SuccessNum: 80, FailureNum: 20}, 9e9, 12e9, 100},
+		{"first half second", TimelinePoint{Second: 10, SuccessNum: 40, FailureNum: 10}, 10.5e9, 12e9, 100},
+		{"last quarter second", TimelinePoint{Second: 11, SuccessNum: 25}, 9e9, 11.25e9, 100},
+		{"run inside the second", TimelinePoint{Second: 10, SuccessNum: 10}, 10.2e9, 10.7e9, 20},
+		{"end not known", TimelinePoint{Second: 10, FailureNum: 7}, 9e9, 0, 7},
+	}
+
+	for _, c := range cases {
+		if rate := c.point.Rate(c.startTime, c.endTime); math.Abs(rate-c.rate) > 1e-9 {
+			t.Errorf("%s rate = %v, want %v", c.name, rate, c.rate)
+		}
+	}
+}
+
+func TestPrintTimeline(t *testing.T) {
+	st := NewResultStatistics(1)
+	start := uint64(100e9)
+	for i := uint64(0); i < 30; i++ {
+		// 10 results in each of the seconds 100, 101 and 102, one failure per second
+		end := start + i*1e8 + 5e7
+		st.AppendRecorded(&runner.TaskResult{Success: i%10 != 0, Category: "c", ProcessTime: 1e6, StartTime: end - 1e6, EndTime: end})
+	}
+	st.Timeline.Seal()
+
+	out := new(bytes.Buffer)
+	TableReporter{Locale: LocaleEnglish, Plain: true, Out: out}.PrintTimeline(st)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 6 || !strings.Contains(lines[1], "rate") {
+		t.Fatalf("timeline is\n%s", out)
+	}
+
+	for i, line := range lines[3:] {
+		cells := strings.Split(line, "|")
+		if strings.TrimSpace(cells[1]) != "9" || strings.TrimSpace(cells[2]) != "1" {
+			t.Errorf("second %d is %s, want 9 successes and 1 failure", i, line)
+		}
+
+		// the run starts at 100.049s and ends at 102.95s, the first and the last seconds are partial
+		expected := []string{"10.52", "10.00", "10.53"}[i]
+		if rate := strings.TrimSpace(cells[3]); rate != expected {
+			t.Errorf("second %d rate is %s, want %s", i, rate, expected)
+		}
+	}
+}
@@ -24,6 +24,7 @@ type TimeBucket struct {
 	ProcessTimeInNanoSec int64
 	SuccessNum           int
 	FailureNum           int
+	Histogram            *Histogram
 }
 
 func (window *TimeWindow) Append(r *runner.TaskResult) {
@@ -45,7 +46,7 @@ func (window *TimeWindow) Append(r *runner.TaskResult) {
 	}
 
 	if bucket == nil {
-		bucket = &TimeBucket{Key: currentTimeInSec, StartTimeInNanoSec: currentTime}
+		bucket = &TimeBucket{Key: currentTimeInSec, StartTimeInNanoSec: currentTime, Histogram: NewHistogram()}
 		window.Buckets = append(window.Buckets, bucket)
 	}
 
@@ -58,6 +59,7 @@ func (window *TimeWindow) Append(r *runner.TaskResult) {
 	}
 
 	bucket.ProcessTimeInNanoSec += int64(r.ProcessTime)
+	bucket.Histogram.Record(r.ProcessTime)
 }
 
 func (window *TimeWindow) Info() (qps float64, speed float64) {
@@ -95,6 +97,19 @@ func (window *TimeWindow) Info() (qps float64, speed float64) {
 	return
 }
 
+// Percentile returns the process time in ms that q percent of the tasks in the window are less than or equal to
+func (window *TimeWindow) Percentile(q float64) float64 {
+	window.Locker.RLock()
+	defer window.Locker.RUnlock()
+
+	histogram := NewHistogram()
+	for _, bucket := range window.Buckets {
+		histogram.Merge(bucket.Histogram)
+	}
+
+	return float64(histogram.Percentile(q)) / 1e6
+}
+
 func (window *TimeWindow) CleanTimeoutBuckets(clearTimeSinceInSec int64) {
 	for len(window.Buckets) > 0 && window.Buckets[0].Key < clearTimeSinceInSec {
 		window.Buckets = window.Buckets[1:]
@@ -1,7 +1,10 @@
 package client
 
 import (
+	"context"
 	"fmt"
+	"math"
+	"strings"
 	"sync"
 	"time"
 
@@ -18,8 +21,30 @@ type StressTestClient struct {
 	Number        int
 	ConcurrentNum int
 	Limitation    int
+	// Duration makes every thread loop until the deadline instead of running Number tasks
+	Duration time.Duration
+	// Rate is the task(s) arriving per second of the open model, see RunWithArrivalRate
+	Rate float64
+	// Poisson spreads the arrivals as a poisson process instead of a fixed interval
+	Poisson bool
+	// MaxConcurrentNum is the thread number the open model is allowed to grow to
+	MaxConcurrentNum int
+	// Stages changes the thread number, or the Rate of the open model, over time. It overrides Number and Duration.
+	// The targets are rates when Rate is set, the Rate itself is only the mode then
+	Stages []Stage
+	// Sinks receive every result of the next run, they are closed when the run is finished
+	Sinks []statistics.ResultSink
+	// Reporters show the next run, nil means the terminal table, see statistics.TableReporter
+	Reporters []statistics.Reporter
+	// AbortWhen is checked every second, the task loops are retired once it returns true
+	AbortWhen func(st *statistics.ResultStatistics) bool
 }
 
+var (
+	stageTickInterval  = 100 * time.Millisecond
+	abortCheckInterval = time.Second
+)
+
 func NewStressClient(number int, concurrent int, limitation int) *StressTestClient {
 	return &StressTestClient{
 		Number:        number,
@@ -36,56 +61,323 @@ func NewStressClientWithConcurrentNumber(number int, concurrent int) *StressTest
 	return NewStressClient(number, concurrent, 0)
 }
 
+func NewStressClientWithArrivalRate(number int, rate float64, concurrent int, maxConcurrent int) *StressTestClient {
+	s := NewStressClient(number, concurrent, 0)
+	s.Rate = rate
+	s.MaxConcurrentNum = maxConcurrent
+	return s
+}
+
+func NewStressClientWithDuration(duration time.Duration, concurrent int) *StressTestClient {
+	s := NewStressClient(0, concurrent, 0)
+	s.Duration = duration
+	return s
+}
+
+// Header prints the load of the run above the terminal table, nothing is printed without the table
 func (s *StressTestClient) Header() {
-	msg := fmt.Sprintf("%d task(s) ready to run with %d thread(s)", s.Number, s.ConcurrentNum)
+	if !s.printsTable() {
+		return
+	}
+
+	var msg string
+	if len(s.Stages) > 0 {
+		var targets []string
+		for _, stage := range s.Stages {
+			targets = append(targets, stage.String())
+		}
+
+		unit := "thread(s)"
+		if s.openModel() {
+			unit = "task(s) per second"
+		}
+
+		fmt.Printf("task(s) ready to run in %d stage(s) of %s for %v: %s\n", len(s.Stages), unit, s.duration(), strings.Join(targets, ", "))
+		fmt.Println()
+		return
+	}
+
+	if s.openModel() {
+		msg = fmt.Sprintf("task(s) arriving at %.2f per second with %d-%d thread(s)", s.Rate, s.ConcurrentNum, s.maxConcurrentNum())
+		if s.Poisson {
+			msg += " in poisson distribution"
+		}
+
+		if s.Duration > 0 {
+			msg += fmt.Sprintf(" for %v", s.Duration)
+		} else {
+			msg += fmt.Sprintf(" until %d task(s) scheduled", s.Number*s.ConcurrentNum)
+		}
+
+		fmt.Println(msg)
+		fmt.Println()
+		return
+	}
+
+	if s.Duration > 0 {
+		msg = fmt.Sprintf("task(s) ready to run for %v with %d thread(s)", s.Duration, s.ConcurrentNum)
+	} else {
+		msg = fmt.Sprintf("%d task(s) ready to run with %d thread(s)", s.Number, s.ConcurrentNum)
+	}
 
 	if s.Limitation > 0 {
-		msg += fmt.Sprintf("%s, with %d task(s) limitation per second", msg, s.Limitation)
+		msg += fmt.Sprintf(", with %d task(s) limitation per second", s.Limitation)
 	}
 
 	fmt.Println(msg)
 	fmt.Println()
 }
 
-func (s *StressTestClient) Run(name string, taskFunc func() error) {
-	s.RunSingleTaskWithRateLimiter(name, nil, taskFunc)
+// printsTable tells whether the run is shown by the terminal table, see statistics.TableReporter
+func (s *StressTestClient) printsTable() bool {
+	if s.Reporters == nil {
+		return true
+	}
+
+	for _, reporter := range s.Reporters {
+		switch reporter.(type) {
+		case statistics.TableReporter, *statistics.TableReporter:
+			return true
+		}
+	}
+
+	return false
+}
+
+// Run runs taskFunc until all tasks are done. Cancelling ctx interrupts the running tasks,
+// the results watched so far are still printed and returned.
+func (s *StressTestClient) Run(ctx context.Context, name string, taskFunc func(ctx context.Context) error) *statistics.ResultStatistics {
+	return s.RunSingleTaskWithRateLimiter(ctx, name, nil, taskFunc)
 }
 
-func (s *StressTestClient) RunSingleTaskWithRateLimiter(name string, rateLimiter ratelimit.Limiter, taskFunc func() error) {
-	s.runWithRateLimiterInternal(rateLimiter, func(num int, wg *sync.WaitGroup, ch chan<- *runner.TaskResult) {
-		runner.RunSync(name, s.Number, ch, wg, taskFunc)
+func (s *StressTestClient) RunSingleTaskWithRateLimiter(ctx context.Context, name string, rateLimiter ratelimit.Limiter, taskFunc func(ctx context.Context) error) *statistics.ResultStatistics {
+	return s.runWithRateLimiterInternal(ctx, rateLimiter, func(ctx context.Context, num int, rateLimiter ratelimit.Limiter, wg *sync.WaitGroup, ch chan<- *runner.TaskResult) {
+		runner.RunSync(ctx, name, num, rateLimiter, ch, wg, taskFunc)
 	})
 }
 
-func (s *StressTestClient) RunMultiTasksWithRateLimiter(name string, rateLimiter ratelimit.Limiter, taskFunc func(ch chan<- *runner.TaskResult) error) {
-	s.runWithRateLimiterInternal(rateLimiter, func(num int, wg *sync.WaitGroup, ch chan<- *runner.TaskResult) {
-		runner.RunSyncWithMultiTasks(s.Number, ch, wg, taskFunc)
+func (s *StressTestClient) RunMultiTasksWithRateLimiter(ctx context.Context, name string, rateLimiter ratelimit.Limiter, taskFunc func(ctx context.Context, ch chan<- *runner.TaskResult) error) *statistics.ResultStatistics {
+	return s.runWithRateLimiterInternal(ctx, rateLimiter, func(ctx context.Context, num int, rateLimiter ratelimit.Limiter, wg *sync.WaitGroup, ch chan<- *runner.TaskResult) {
+		runner.RunSyncWithMultiTasks(ctx, num, rateLimiter, ch, wg, taskFunc)
 	})
 }
 
-func (s *StressTestClient) runWithRateLimiterInternal(rateLimiter ratelimit.Limiter, taskFunc func(num int, wg *sync.WaitGroup, ch chan<- *runner.TaskResult)) {
-	ch := make(chan *runner.TaskResult, 1000)
+// RunWithArrivalRate schedules Rate task(s) per second no matter how fast the tasks finish (open model),
+// the thread pool grows from ConcurrentNum up to MaxConcurrentNum when tasks back up.
+// With Stages the targets are the rate instead. Nothing runs without a Rate, the statistics are empty then.
+func (s *StressTestClient) RunWithArrivalRate(ctx context.Context, name string, taskFunc func(ctx context.Context) error) *statistics.ResultStatistics {
+	st := s.newStatistics()
+	if !s.openModel() {
+		return st
+	}
+
+	st.TargetRate = s.Rate
+	st.OpenModel = true
+
+	rateAt := func(elapsed time.Duration) float64 {
+		return s.Rate
+	}
+
+	if len(s.Stages) > 0 {
+		st.Staged = true
+		rateAt = func(elapsed time.Duration) float64 {
+			rate := TargetAt(s.Stages, elapsed)
+			st.SetTargetRate(rate)
+			return rate
+		}
+	}
+
+	s.runInternal(ctx, st, func(ctx context.Context, ch chan<- *runner.TaskResult) {
+		num := s.iterations() * s.ConcurrentNum
+		runner.RunArrivalRateWithProfile(ctx, name, num, rateAt, s.Poisson, s.ConcurrentNum, s.maxConcurrentNum(), ch, taskFunc)
+	})
+
+	return st
+}
+
+// MaxThreads returns the most threads the run may start, e.g. to partition the test data by thread
+func (s *StressTestClient) MaxThreads() int {
+	if s.openModel() {
+		return s.maxConcurrentNum()
+	}
+
+	if len(s.Stages) > 0 {
+		max := 0
+		for _, stage := range s.Stages {
+			if target := int(math.Round(stage.Target)); target > max {
+				max = target
+			}
+		}
+
+		return max
+	}
+
+	return s.ConcurrentNum
+}
+
+// openModel tells whether the client runs the open model of RunWithArrivalRate, its stage targets are rates then
+func (s *StressTestClient) openModel() bool {
+	return s.Rate > 0
+}
+
+func (s *StressTestClient) maxConcurrentNum() int {
+	if s.MaxConcurrentNum < s.ConcurrentNum {
+		return s.ConcurrentNum
+	}
+
+	return s.MaxConcurrentNum
+}
+
+// iterations returns the task number of every thread, 0 means running until the deadline
+func (s *StressTestClient) iterations() int {
+	if s.duration() > 0 {
+		return 0
+	}
+
+	return s.Number
+}
+
+func (s *StressTestClient) duration() time.Duration {
+	if len(s.Stages) > 0 {
+		return StagesDuration(s.Stages)
+	}
+
+	return s.Duration
+}
+
+// rateLimiter is shared by all threads, it falls back to Limitation task(s) per second when nil
+func (s *StressTestClient) runWithRateLimiterInternal(ctx context.Context, rateLimiter ratelimit.Limiter, taskFunc func(ctx context.Context, num int, rateLimiter ratelimit.Limiter, wg *sync.WaitGroup, ch chan<- *runner.TaskResult)) *statistics.ResultStatistics {
+	if rateLimiter == nil && s.Limitation > 0 {
+		rateLimiter = ratelimit.New(s.Limitation)
+	}
+
+	st := s.newStatistics()
+	st.TargetRate = float64(s.Limitation)
+
+	if len(s.Stages) > 0 {
+		st.Staged = true
+		s.runInternal(ctx, st, func(ctx context.Context, ch chan<- *runner.TaskResult) {
+			s.runStages(ctx, rateLimiter, st, ch, taskFunc)
+		})
+		return st
+	}
+
+	s.runInternal(ctx, st, func(ctx context.Context, ch chan<- *runner.TaskResult) {
+		wg := new(sync.WaitGroup)
+
+		num := s.iterations()
+		for i := 0; i < s.ConcurrentNum; i++ {
+			wg.Add(1)
+			go taskFunc(runner.WithWorker(ctx, i+1), num, rateLimiter, wg, ch)
+		}
+		wg.Wait()
+	})
+
+	return st
+}
+
+// runStages adds and retires threads to follow the target concurrency of the stages,
+// a retired thread stops after its running task
+func (s *StressTestClient) runStages(ctx context.Context, rateLimiter ratelimit.Limiter, st *statistics.ResultStatistics, ch chan<- *runner.TaskResult, taskFunc func(ctx context.Context, num int, rateLimiter ratelimit.Limiter, wg *sync.WaitGroup, ch chan<- *runner.TaskResult)) {
 	wg := new(sync.WaitGroup)
-	wgStatistics := new(sync.WaitGroup)
+	ticker := time.NewTicker(stageTickInterval)
+	defer ticker.Stop()
+
+	var retires []func()
+	// workerID only grows, a thread started after a ramp-down does not take over the id of a retired one
+	workerID := 0
+	startTime := time.Now()
+	for {
+		target := int(math.Round(TargetAt(s.Stages, time.Since(startTime))))
+		for len(retires) < target {
+			threadCtx, retire := runner.WithRetire(ctx)
+			retires = append(retires, retire)
+
+			workerID++
+			wg.Add(1)
+			go taskFunc(runner.WithWorker(threadCtx, workerID), 0, rateLimiter, wg, ch)
+		}
+
+		for len(retires) > target {
+			retires[len(retires)-1]()
+			retires = retires[:len(retires)-1]
+		}
 
+		st.SetConcurrentNum(target)
+
+		select {
+		case <-ticker.C:
+			continue
+		case <-ctx.Done():
+		case <-runner.Retired(ctx):
+		}
+
+		for _, retire := range retires {
+			retire()
+		}
+
+		wg.Wait()
+		return
+	}
+}
+
+func (s *StressTestClient) newStatistics() *statistics.ResultStatistics {
 	st := statistics.NewResultStatistics(s.ConcurrentNum)
+	st.Reporters = s.Reporters
+	for _, sink := range s.Sinks {
+		st.AddSink(sink)
+	}
+
+	return st
+}
+
+// runInternal watches the results of execute in st until execute returns.
+// The task loops are retired gracefully after the duration, cancelling ctx interrupts the running tasks.
+func (s *StressTestClient) runInternal(ctx context.Context, st *statistics.ResultStatistics, execute func(ctx context.Context, ch chan<- *runner.TaskResult)) {
+	ch := make(chan *runner.TaskResult, 1000)
+	wgStatistics := new(sync.WaitGroup)
+
+	ctx, retire := runner.WithRetire(ctx)
+	defer retire()
+
+	if d := s.duration(); d > 0 {
+		timer := time.AfterFunc(d, retire)
+		defer timer.Stop()
+	}
+
+	if s.AbortWhen != nil {
+		go s.watchAbort(ctx, st, retire)
+	}
 
 	wgStatistics.Add(1)
 	go st.Watch(ch, wgStatistics)
 
-	for i := 0; i < s.ConcurrentNum; i++ {
-		wg.Add(1)
-		go func() {
-			if rateLimiter != nil {
-				rateLimiter.Take()
-			}
-			taskFunc(s.Number, wg, ch)
-		}()
+	// a fixed number of 0 tasks runs nothing instead of looping without a deadline
+	if s.duration() > 0 || s.Number > 0 {
+		execute(ctx, ch)
 	}
-	wg.Wait()
 
 	time.Sleep(1 * time.Millisecond)
 	close(ch)
 
 	wgStatistics.Wait()
 }
+
+func (s *StressTestClient) watchAbort(ctx context.Context, st *statistics.ResultStatistics, retire func()) {
+	ticker := time.NewTicker(abortCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if s.AbortWhen(st) {
+				retire()
+				return
+			}
+		case <-ctx.Done():
+			return
+		case <-runner.Retired(ctx):
+			return
+		}
+	}
+}
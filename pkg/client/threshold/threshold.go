@@ -0,0 +1,239 @@
+package threshold
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ginkgoch/stress-test/pkg/client/statistics"
+)
+
+type unit int
+
+const (
+	unitMs unit = iota
+	unitPercent
+	unitRate
+	unitCount
+)
+
+// Threshold is a pass condition of a run, e.g. p95<300ms, error_rate<1%, qps>800 or start-game:p99<2s
+type Threshold struct {
+	Expr string
+	// Category is empty for all results
+	Category string
+	Metric   string
+	Operator string
+	// Value is in ms for the time metrics and in percent for error_rate
+	Value float64
+	q     float64
+	unit  unit
+}
+
+// Violation is a threshold not passed, Missing means there is no result to evaluate
+type Violation struct {
+	Threshold *Threshold
+	Actual    float64
+	Missing   bool
+}
+
+var operators = []string{"<=", ">=", "==", "!=", "<", ">"}
+
+// Parse parses [category:]metric<operator>value. The metrics are
+// p<q> e.g. p95 and p99.9, mean, min, max, error_rate, qps, rate, total, success, failure, dropped and late.
+// The time values accept a unit like 300ms or 2s and default to ms, error_rate is in percent
+func Parse(expr string) (*Threshold, error) {
+	opIndex, op := -1, ""
+	for i := 0; i < len(expr) && opIndex < 0; i++ {
+		for _, candidate := range operators {
+			if strings.HasPrefix(expr[i:], candidate) {
+				opIndex, op = i, candidate
+				break
+			}
+		}
+	}
+
+	if opIndex <= 0 {
+		return nil, fmt.Errorf("invalid threshold <%s>, expected e.g. p95<300ms", expr)
+	}
+
+	t := &Threshold{Expr: expr, Operator: op}
+
+	left := strings.TrimSpace(expr[:opIndex])
+	if i := strings.LastIndex(left, ":"); i >= 0 {
+		t.Category = strings.TrimSpace(left[:i])
+		left = strings.TrimSpace(left[i+1:])
+	}
+
+	t.Metric = strings.ToLower(left)
+	if err := t.parseMetric(); err != nil {
+		return nil, fmt.Errorf("invalid threshold <%s> - %v", expr, err)
+	}
+
+	value, err := t.parseValue(strings.TrimSpace(expr[opIndex+len(op):]))
+	if err != nil {
+		return nil, fmt.Errorf("invalid threshold <%s> - %v", expr, err)
+	}
+
+	t.Value = value
+	return t, nil
+}
+
+// ParseAll parses the thresholds, the first invalid one fails all of them
+func ParseAll(exprs []string) ([]*Threshold, error) {
+	var result []*Threshold
+	for _, expr := range exprs {
+		t, err := Parse(expr)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, t)
+	}
+
+	return result, nil
+}
+
+func (t *Threshold) parseMetric() error {
+	switch t.Metric {
+	case "mean", "avg", "min", "max":
+		t.unit = unitMs
+	case "error_rate":
+		t.unit = unitPercent
+	case "qps", "rate":
+		t.unit = unitRate
+	case "total", "success", "failure", "dropped", "late":
+		t.unit = unitCount
+	default:
+		name := strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(t.Metric, "p("), "p"), ")")
+		q, err := strconv.ParseFloat(name, 64)
+		if !strings.HasPrefix(t.Metric, "p") || err != nil || q <= 0 || q > 100 {
+			return fmt.Errorf("unknown metric <%s>", t.Metric)
+		}
+
+		t.q = q
+		t.unit = unitMs
+	}
+
+	return nil
+}
+
+func (t *Threshold) parseValue(value string) (float64, error) {
+	switch t.unit {
+	case unitMs:
+		if number, err := strconv.ParseFloat(strings.TrimSuffix(value, "ms"), 64); err == nil {
+			return number, nil
+		}
+
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration <%s>", value)
+		}
+
+		return float64(d) / 1e6, nil
+	case unitPercent:
+		return strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+	default:
+		return strconv.ParseFloat(value, 64)
+	}
+}
+
+// Actual returns the value of the metric in st, false when the category has no results
+func (t *Threshold) Actual(st *statistics.ResultStatistics) (actual float64, ok bool) {
+	ok = st.View(t.Category, func(m *statistics.Metrics, runningTime uint64) {
+		switch t.Metric {
+		case "mean", "avg":
+			if m.TotalNum() > 0 {
+				actual = m.AverageTime()
+			}
+		case "min":
+			actual = float64(m.MinTime) / 1e6
+		case "max":
+			actual = float64(m.MaxTime) / 1e6
+		case "error_rate":
+			actual = m.ErrorRate()
+		case "qps":
+			if runningTime > 0 {
+				actual = m.Qps(runningTime)
+			}
+		case "rate":
+			if runningTime > 0 {
+				actual = m.Rate(runningTime)
+			}
+		case "total":
+			actual = float64(m.TotalNum())
+		case "success":
+			actual = float64(m.SuccessNum)
+		case "failure":
+			actual = float64(m.FailureNum)
+		case "dropped":
+			actual = float64(m.DroppedNum)
+		case "late":
+			actual = float64(m.LateNum)
+		default:
+			actual = m.Percentile(t.q)
+		}
+	})
+
+	return
+}
+
+// Passed tells whether actual meets the threshold
+func (t *Threshold) Passed(actual float64) bool {
+	switch t.Operator {
+	case "<":
+		return actual < t.Value
+	case "<=":
+		return actual <= t.Value
+	case ">":
+		return actual > t.Value
+	case ">=":
+		return actual >= t.Value
+	case "==":
+		return actual == t.Value
+	default:
+		return actual != t.Value
+	}
+}
+
+// Format formats value in the unit of the metric
+func (t *Threshold) Format(value float64) string {
+	switch t.unit {
+	case unitMs:
+		return fmt.Sprintf("%.2fms", value)
+	case unitPercent:
+		return fmt.Sprintf("%.2f%%", value)
+	case unitRate:
+		return fmt.Sprintf("%.2f/s", value)
+	default:
+		return fmt.Sprintf("%.0f", value)
+	}
+}
+
+func (t *Threshold) String() string {
+	return t.Expr
+}
+
+// Check evaluates the thresholds against st, a threshold of a category without results is violated
+func Check(st *statistics.ResultStatistics, thresholds []*Threshold) []*Violation {
+	var violations []*Violation
+	for _, t := range thresholds {
+		actual, ok := t.Actual(st)
+		if !ok {
+			violations = append(violations, &Violation{Threshold: t, Missing: true})
+		} else if !t.Passed(actual) {
+			violations = append(violations, &Violation{Threshold: t, Actual: actual})
+		}
+	}
+
+	return violations
+}
+
+func (v *Violation) String() string {
+	if v.Missing {
+		return fmt.Sprintf("%s, no result of category <%s>", v.Threshold, v.Threshold.Category)
+	}
+
+	return fmt.Sprintf("%s, actual %s", v.Threshold, v.Threshold.Format(v.Actual))
+}
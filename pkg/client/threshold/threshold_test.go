@@ -0,0 +1,114 @@
+package threshold
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ginkgoch/stress-test/pkg/client/runner"
+	"github.com/ginkgoch/stress-test/pkg/client/statistics"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		expr     string
+		category string
+		metric   string
+		operator string
+		value    float64
+	}{
+		{"p95<300ms", "", "p95", "<", 300},
+		{"p95<300", "", "p95", "<", 300},
+		{"p99<2s", "", "p99", "<", 2000},
+		{"p99.9<=1.5s", "", "p99.9", "<=", 1500},
+		{"p(90) < 250ms", "", "p(90)", "<", 250},
+		{"mean<100us", "", "mean", "<", 0.1},
+		{"error_rate<1%", "", "error_rate", "<", 1},
+		{"error_rate<=0.5", "", "error_rate", "<=", 0.5},
+		{"qps>800", "", "qps", ">", 800},
+		{"QPS>=800", "", "qps", ">=", 800},
+		{"failure==0", "", "failure", "==", 0},
+		{"dropped!=1", "", "dropped", "!=", 1},
+		{"start-game:p99<2s", "start-game", "p99", "<", 2000},
+		{"a:b:max<1s", "a:b", "max", "<", 1000},
+	}
+
+	for _, c := range cases {
+		threshold, err := Parse(c.expr)
+		if err != nil {
+			t.Errorf("Parse(%q) failed - %v", c.expr, err)
+			continue
+		}
+
+		if threshold.Category != c.category || threshold.Metric != c.metric || threshold.Operator != c.operator || threshold.Value != c.value {
+			t.Errorf("Parse(%q) = %q %q %q %v, want %q %q %q %v", c.expr,
+				threshold.Category, threshold.Metric, threshold.Operator, threshold.Value,
+				c.category, c.metric, c.operator, c.value)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, expr := range []string{"", "p95", "<300", "p0<1", "p101<1", "pp<1", "latency<1", "p95<fast", "qps>many", "error_rate<x%"} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) should fail", expr)
+		}
+	}
+
+	if _, err := ParseAll([]string{"p95<300ms", "unknown<1"}); err == nil {
+		t.Error("ParseAll should fail with an invalid threshold")
+	}
+}
+
+func TestPassed(t *testing.T) {
+	cases := []struct {
+		expr   string
+		actual float64
+		passed bool
+	}{
+		{"p95<300", 299, true},
+		{"p95<300", 300, false},
+		{"p95<=300", 300, true},
+		{"qps>10", 10, false},
+		{"qps>=10", 10, true},
+		{"failure==0", 0, true},
+		{"failure!=0", 0, false},
+	}
+
+	for _, c := range cases {
+		threshold, err := Parse(c.expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if passed := threshold.Passed(c.actual); passed != c.passed {
+			t.Errorf("%s with %v passed %v, want %v", c.expr, c.actual, passed, c.passed)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	st := statistics.NewResultStatistics(1)
+	start := uint64(time.Now().UnixNano())
+	for i := 1; i <= 100; i++ {
+		r := &runner.TaskResult{Success: i <= 98, Category: "login", ProcessTime: uint64(i) * 1e6, StartTime: start, EndTime: start + uint64(i)*1e6}
+		st.AppendRecorded(r)
+	}
+
+	thresholds, err := ParseAll([]string{"p50<=51ms", "error_rate<1%", "login:max<=100ms", "logout:p99<1s", "failure==2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	violations := Check(st, thresholds)
+	if len(violations) != 2 {
+		t.Fatalf("%d violation(s) %v, want 2", len(violations), violations)
+	}
+
+	if violations[0].Threshold.Expr != "error_rate<1%" || violations[0].Actual != 2 {
+		t.Errorf("violation %v, want error_rate of 2%%", violations[0])
+	}
+
+	if violations[1].Threshold.Category != "logout" || !violations[1].Missing {
+		t.Errorf("violation %v, want the missing logout", violations[1])
+	}
+}
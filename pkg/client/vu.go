@@ -0,0 +1,266 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"os"
+	"sync"
+
+	"github.com/ginkgoch/stress-test/pkg/client/runner"
+	"github.com/ginkgoch/stress-test/pkg/client/statistics"
+)
+
+// VirtualUser is the lifecycle of the virtual users of RunVirtualUsers, every thread runs as one virtual user.
+// Iteration or MultiIteration is required, the other hooks are optional
+type VirtualUser struct {
+	// Name is the category of the iterations
+	Name string
+	// Client is copied for every virtual user with its own cookie jar, default http.Client{}
+	Client *http.Client
+	// NoCookieJar leaves the clients without cookie jar, e.g. when the hooks send the cookies themselves
+	NoCookieJar bool
+	// GlobalSetup runs once before the virtual users are set up, its error cancels the run
+	GlobalSetup func(ctx context.Context) error
+	// Setup prepares a virtual user before the measurement, e.g. signs in. The first iteration of a user
+	// failed to set up fails with SetupError, then its thread stops. With a rate every iteration of the user fails
+	Setup func(ctx context.Context, vu *VU) error
+	// Iteration runs one iteration measured as a result of category Name
+	Iteration func(ctx context.Context, vu *VU) error
+	// MultiIteration runs one iteration writing its own results, it does not support the open model
+	MultiIteration func(ctx context.Context, vu *VU, ch chan<- *runner.TaskResult) error
+	// Teardown cleans up a virtual user after the run, even an interrupted one
+	Teardown func(ctx context.Context, vu *VU) error
+	// GlobalTeardown runs once after the virtual users are torn down
+	GlobalTeardown func(ctx context.Context) error
+}
+
+// VU is the identity and the state of one virtual user, it is only used by its own thread
+type VU struct {
+	// ID is the 1-based id of the virtual user, the same as the worker id of its thread
+	ID int
+	// Iteration is the 0-based iteration of the virtual user
+	Iteration int
+	// Client is the http client of the virtual user with its own cookie jar
+	Client *http.Client
+	// State keeps the data of the virtual user between the hooks, e.g. the session
+	State interface{}
+
+	iterations int
+	setupErr   error
+	reported   bool
+	once       sync.Once
+}
+
+// SetupError fails the iterations of a virtual user failed to set up
+type SetupError struct {
+	ID  int
+	Err error
+}
+
+func (e *SetupError) Error() string {
+	return fmt.Sprintf("virtual user %d setup failed - %v", e.ID, e.Err)
+}
+
+func (e *SetupError) Unwrap() error {
+	return e.Err
+}
+
+func (e *SetupError) ErrorClass() string {
+	return "setup"
+}
+
+type vuKey struct{}
+
+// WithVU returns ctx of the hooks of vu, see CurrentVU
+func WithVU(ctx context.Context, vu *VU) context.Context {
+	return context.WithValue(ctx, vuKey{}, vu)
+}
+
+// CurrentVU returns the virtual user running the hook of ctx, nil when it is not in RunVirtualUsers
+func CurrentVU(ctx context.Context) *VU {
+	vu, _ := ctx.Value(vuKey{}).(*VU)
+	return vu
+}
+
+// RunVirtualUsers runs the iterations of the virtual users as RunSingleTaskWithRateLimiter, RunMultiTasksWithRateLimiter,
+// or RunWithArrivalRate when Rate is set. The global setup and the setup of MaxThreads virtual users, or ConcurrentNum
+// ones with a rate, run before the measurement, a thread started later sets up its virtual user on its first iteration
+func (s *StressTestClient) RunVirtualUsers(ctx context.Context, user *VirtualUser) (*statistics.ResultStatistics, error) {
+	if (user.Iteration == nil) == (user.MultiIteration == nil) {
+		return nil, fmt.Errorf("virtual user <%s> needs either Iteration or MultiIteration", user.Name)
+	}
+
+	if user.MultiIteration != nil && s.Rate > 0 {
+		return nil, fmt.Errorf("virtual user <%s> of multiple tasks does not support the arrival rate", user.Name)
+	}
+
+	if user.GlobalSetup != nil {
+		if err := user.GlobalSetup(ctx); err != nil {
+			return nil, fmt.Errorf("global setup failed - %v", err)
+		}
+	}
+
+	pool := newVUPool(user)
+	pool.stopOnFailure = s.Rate == 0
+	// the open model starts most of its MaxConcurrentNum threads only when the arrivals fall behind
+	if s.Rate > 0 {
+		pool.setupAll(ctx, s.ConcurrentNum)
+	} else {
+		pool.setupAll(ctx, s.MaxThreads())
+	}
+
+	var st *statistics.ResultStatistics
+	if user.MultiIteration != nil {
+		st = s.RunMultiTasksWithRateLimiter(ctx, user.Name, nil, func(ctx context.Context, ch chan<- *runner.TaskResult) error {
+			vu, err := pool.next(ctx)
+			if err != nil {
+				runner.RunStep(ctx, user.Name, ch, func(ctx context.Context) error { return err })
+				return err
+			}
+
+			return user.MultiIteration(WithVU(ctx, vu), vu, ch)
+		})
+	} else {
+		taskFunc := func(ctx context.Context) error {
+			vu, err := pool.next(ctx)
+			if err != nil {
+				return err
+			}
+
+			return user.Iteration(WithVU(ctx, vu), vu)
+		}
+
+		if s.Rate > 0 {
+			st = s.RunWithArrivalRate(ctx, user.Name, taskFunc)
+		} else {
+			st = s.RunSingleTaskWithRateLimiter(ctx, user.Name, nil, taskFunc)
+		}
+	}
+
+	pool.teardownAll()
+
+	if user.GlobalTeardown != nil {
+		if err := user.GlobalTeardown(context.Background()); err != nil {
+			return st, fmt.Errorf("global teardown failed - %v", err)
+		}
+	}
+
+	return st, nil
+}
+
+// vuPool keeps the virtual users by worker id
+type vuPool struct {
+	user  *VirtualUser
+	users map[int]*VU
+	// stopOnFailure stops the thread of a user failed to set up after its first failed iteration
+	stopOnFailure bool
+	locker        sync.Mutex
+}
+
+func newVUPool(user *VirtualUser) *vuPool {
+	return &vuPool{user: user, users: make(map[int]*VU)}
+}
+
+// get returns the virtual user of id, it is set up on the first call
+func (p *vuPool) get(ctx context.Context, id int) *VU {
+	p.locker.Lock()
+	vu, ok := p.users[id]
+	if !ok {
+		vu = &VU{ID: id, Client: p.newClient()}
+		p.users[id] = vu
+	}
+	p.locker.Unlock()
+
+	vu.once.Do(func() {
+		if p.user.Setup != nil {
+			vu.setupErr = p.user.Setup(WithVU(runner.WithWorker(ctx, id), vu), vu)
+		}
+	})
+
+	return vu
+}
+
+// next returns the virtual user of the thread running ctx for its next iteration
+func (p *vuPool) next(ctx context.Context) (*VU, error) {
+	vu := p.get(ctx, runner.WorkerID(ctx))
+	if vu.setupErr != nil {
+		if p.stopOnFailure && vu.reported {
+			return nil, fmt.Errorf("virtual user %d failed to set up - %w", vu.ID, runner.ErrStop)
+		}
+
+		vu.reported = true
+		return nil, &SetupError{ID: vu.ID, Err: vu.setupErr}
+	}
+
+	vu.Iteration = vu.iterations
+	vu.iterations++
+	return vu, nil
+}
+
+func (p *vuPool) newClient() *http.Client {
+	c := new(http.Client)
+	if p.user.Client != nil {
+		*c = *p.user.Client
+	}
+
+	if !p.user.NoCookieJar {
+		c.Jar, _ = cookiejar.New(nil)
+	}
+
+	return c
+}
+
+// setupAll sets up the virtual users 1 to n at the same time, the failures are reported once
+func (p *vuPool) setupAll(ctx context.Context, n int) {
+	if p.user.Setup == nil {
+		return
+	}
+
+	wg := new(sync.WaitGroup)
+	for id := 1; id <= n; id++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			p.get(ctx, id)
+		}(id)
+	}
+	wg.Wait()
+
+	failed := 0
+	var sample error
+	for _, vu := range p.users {
+		if vu.setupErr != nil {
+			failed++
+			sample = vu.setupErr
+		}
+	}
+
+	if failed > 0 {
+		fmt.Fprintf(os.Stderr, "%d of %d virtual user(s) failed to set up, e.g. %v\n", failed, n, sample)
+	}
+}
+
+// teardownAll tears down the virtual users set up successfully, the run ctx may be cancelled already
+func (p *vuPool) teardownAll() {
+	if p.user.Teardown == nil {
+		return
+	}
+
+	wg := new(sync.WaitGroup)
+	for _, vu := range p.users {
+		if vu.setupErr != nil {
+			continue
+		}
+
+		wg.Add(1)
+		go func(vu *VU) {
+			defer wg.Done()
+			if err := p.user.Teardown(WithVU(runner.WithWorker(context.Background(), vu.ID), vu), vu); err != nil {
+				fmt.Fprintf(os.Stderr, "virtual user %d teardown failed - %v\n", vu.ID, err)
+			}
+		}(vu)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,195 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ginkgoch/stress-test/pkg/client/runner"
+	"github.com/ginkgoch/stress-test/pkg/client/statistics"
+)
+
+// lifecycle records the hooks of a virtual user
+type lifecycle struct {
+	locker     sync.Mutex
+	events     []string
+	iterations map[int][]int
+	setups     int64
+	teardowns  map[int]bool
+}
+
+func newLifecycle() *lifecycle {
+	return &lifecycle{iterations: make(map[int][]int), teardowns: make(map[int]bool)}
+}
+
+func (l *lifecycle) user(setupErr func(id int) error) *VirtualUser {
+	return &VirtualUser{
+		Name: "vu",
+		GlobalSetup: func(ctx context.Context) error {
+			l.event("global setup")
+			return nil
+		},
+		Setup: func(ctx context.Context, vu *VU) error {
+			atomic.AddInt64(&l.setups, 1)
+			if CurrentVU(ctx) != vu || runner.WorkerID(ctx) != vu.ID {
+				return errors.New("setup runs out of its virtual user")
+			}
+
+			vu.State = vu.ID * 10
+			return setupErr(vu.ID)
+		},
+		Iteration: func(ctx context.Context, vu *VU) error {
+			if CurrentVU(ctx) != vu || vu.State != vu.ID*10 {
+				return errors.New("iteration runs out of its virtual user")
+			}
+
+			l.locker.Lock()
+			l.iterations[vu.ID] = append(l.iterations[vu.ID], vu.Iteration)
+			l.locker.Unlock()
+			return nil
+		},
+		Teardown: func(ctx context.Context, vu *VU) error {
+			l.locker.Lock()
+			l.teardowns[vu.ID] = true
+			l.locker.Unlock()
+			return nil
+		},
+		GlobalTeardown: func(ctx context.Context) error {
+			l.event("global teardown")
+			return nil
+		},
+	}
+}
+
+func (l *lifecycle) event(name string) {
+	l.locker.Lock()
+	l.events = append(l.events, name)
+	l.locker.Unlock()
+}
+
+func quietClient(s *StressTestClient) *StressTestClient {
+	s.Reporters = []statistics.Reporter{}
+	return s
+}
+
+func TestRunVirtualUsers(t *testing.T) {
+	l := newLifecycle()
+	s := quietClient(NewStressClientWithConcurrentNumber(3, 2))
+
+	st, err := s.RunVirtualUsers(context.Background(), l.user(func(id int) error { return nil }))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if st.SuccessNum != 6 || st.FailureNum != 0 {
+		t.Errorf("success %d, failure %d, want 6 and 0", st.SuccessNum, st.FailureNum)
+	}
+
+	if l.setups != 2 || len(l.teardowns) != 2 {
+		t.Errorf("%d setup(s) and %d teardown(s), want 2", l.setups, len(l.teardowns))
+	}
+
+	for id := 1; id <= 2; id++ {
+		if iterations := l.iterations[id]; len(iterations) != 3 || iterations[0] != 0 || iterations[2] != 2 {
+			t.Errorf("virtual user %d iterations are %v, want [0 1 2]", id, iterations)
+		}
+	}
+
+	if len(l.events) != 2 || l.events[0] != "global setup" || l.events[1] != "global teardown" {
+		t.Errorf("global events are %v", l.events)
+	}
+}
+
+func TestRunVirtualUsersStopsOnSetupFailure(t *testing.T) {
+	l := newLifecycle()
+	s := quietClient(NewStressClientWithConcurrentNumber(3, 2))
+
+	st, err := s.RunVirtualUsers(context.Background(), l.user(func(id int) error {
+		if id == 2 {
+			return errors.New("sign in failed")
+		}
+
+		return nil
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the user failed to set up fails its first iteration, then its thread stops
+	if st.SuccessNum != 3 || st.FailureNum != 1 {
+		t.Errorf("success %d, failure %d, want 3 and 1", st.SuccessNum, st.FailureNum)
+	}
+
+	if len(l.iterations[2]) != 0 || l.teardowns[2] || !l.teardowns[1] {
+		t.Errorf("virtual user 2 failed to set up runs %v, teardowns %v", l.iterations[2], l.teardowns)
+	}
+}
+
+func TestRunVirtualUsersWithRate(t *testing.T) {
+	l := newLifecycle()
+	user := l.user(func(id int) error { return nil })
+
+	var setupsBeforeRun int64 = -1
+	iteration := user.Iteration
+	user.Iteration = func(ctx context.Context, vu *VU) error {
+		atomic.CompareAndSwapInt64(&setupsBeforeRun, -1, atomic.LoadInt64(&l.setups))
+		return iteration(ctx, vu)
+	}
+
+	st, err := quietClient(NewStressClientWithArrivalRate(5, 100, 2, 50)).RunVirtualUsers(context.Background(), user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if st.SuccessNum != 10 || st.FailureNum != 0 {
+		t.Errorf("success %d, failure %d, want 10 and 0", st.SuccessNum, st.FailureNum)
+	}
+
+	// only the pre-allocated users are set up before the run, the others when their threads start
+	if setupsBeforeRun != 2 {
+		t.Errorf("%d setup(s) before the run, want 2", setupsBeforeRun)
+	}
+
+	if int(l.setups) != len(l.teardowns) {
+		t.Errorf("%d setup(s) and %d teardown(s)", l.setups, len(l.teardowns))
+	}
+
+	// every arrival served by a user failed to set up fails, the threads of the open model go on
+	l = newLifecycle()
+	st, err = quietClient(NewStressClientWithArrivalRate(5, 100, 2, 50)).RunVirtualUsers(context.Background(), l.user(func(id int) error {
+		return errors.New("sign in failed")
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if st.SuccessNum != 0 || st.FailureNum != 10 {
+		t.Errorf("success %d, failure %d, want 0 and 10", st.SuccessNum, st.FailureNum)
+	}
+}
+
+func TestRunVirtualUsersFails(t *testing.T) {
+	iteration := func(ctx context.Context, vu *VU) error { return nil }
+	multiIteration := func(ctx context.Context, vu *VU, ch chan<- *runner.TaskResult) error { return nil }
+
+	cases := []struct {
+		name   string
+		client *StressTestClient
+		user   *VirtualUser
+	}{
+		{"no iteration", NewStressClientWithNumber(1), &VirtualUser{Name: "vu"}},
+		{"both iterations", NewStressClientWithNumber(1), &VirtualUser{Name: "vu", Iteration: iteration, MultiIteration: multiIteration}},
+		{"multiple tasks with rate", NewStressClientWithArrivalRate(1, 10, 1, 1), &VirtualUser{Name: "vu", MultiIteration: multiIteration}},
+		{"global setup", NewStressClientWithNumber(1), &VirtualUser{Name: "vu", Iteration: iteration, GlobalSetup: func(ctx context.Context) error {
+			return errors.New("no database")
+		}}},
+	}
+
+	for _, c := range cases {
+		if _, err := quietClient(c.client).RunVirtualUsers(context.Background(), c.user); err == nil {
+			t.Errorf("%s: RunVirtualUsers should fail", c.name)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path"
+
+	"github.com/ginkgoch/stress-test/pkg/client/report"
+	"github.com/ginkgoch/stress-test/pkg/client/statistics"
+	"github.com/spf13/cobra"
+)
+
+var (
+	tolerance      float64
+	errorTolerance float64
+	plainCompare   bool
+)
+
+func init() {
+	compareCmd.Flags().Float64VarP(&tolerance, "tolerance", "", 10, "--tolerance <percent>, qps and latency changes worse than it are regressions, default 10")
+	compareCmd.Flags().Float64VarP(&errorTolerance, "errorTolerance", "", 1, "--errorTolerance <percentage points>, error rate increase more than it is a regression, default 1")
+
+	compareCmd.Flags().BoolVarP(&plainCompare, "plain", "", false, "--plain, draws the table in ascii for ci logs, the same as --outputFormat plain")
+
+	rootCmd.AddCommand(compareCmd)
+}
+
+var compareCmd = &cobra.Command{
+	Use:   "compare <old> <new>",
+	Short: "Compare two runs and detect the regressions",
+	Long:  `Compare the throughput, error rate and latency percentiles of two runs per category, the runs are json summaries written by --out or raw result files written by --rawOut. It exits with code 2 when any regression is found`,
+	Args:  cobra.ExactArgs(2),
+	Example: `stress-test compare old.json new.json
+stress-test compare old.jsonl new.jsonl --tolerance 5 --errorTolerance 0.5`,
+	Run: func(cmd *cobra.Command, args []string) {
+		before := loadComparedSummary(args[0])
+		after := loadComparedSummary(args[1])
+
+		comparison := report.Compare(before, after, tolerance, errorTolerance)
+		fmt.Printf("comparing %s (old) with %s (new)\n\n", args[0], args[1])
+		comparison.Print(newCompareTable())
+
+		fmt.Println()
+		if regressions := comparison.Regressions(); len(regressions) > 0 {
+			fmt.Fprintf(os.Stderr, "%d regression(s) found, tolerance %v%%, error rate tolerance %vpp\n", len(regressions), tolerance, errorTolerance)
+			os.Exit(2)
+		}
+
+		fmt.Println("no regression found")
+	},
+}
+
+// newCompareTable returns the table of --locale, default en, in ascii with --plain or --outputFormat plain
+func newCompareTable() statistics.TableReporter {
+	t := statistics.TableReporter{Locale: locale, Plain: plainCompare || outputFormat == statistics.OutputFormatPlain}
+	if t.Locale == "" {
+		t.Locale = statistics.LocaleEnglish
+	}
+
+	if _, err := statistics.NewReporter(outputFormat, t.Locale); err != nil {
+		log.Fatalln(err)
+	}
+
+	return t
+}
+
+// loadComparedSummary loads a json summary, or rebuilds the summary from a raw result file
+func loadComparedSummary(filepath string) *statistics.Summary {
+	if path.Ext(filepath) == ".jsonl" {
+		return LoadRawResults(filepath).Summary()
+	}
+
+	summary, err := report.ReadSummary(filepath)
+	if err != nil {
+		log.Fatalf("read summary <%s> failed - %v\n", filepath, err)
+	}
+
+	return summary
+}
@@ -1,15 +1,18 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"net/http"
+	"net/url"
 	"strings"
 
-	"github.com/ginkgoch/stress-test/pkg/client"
+	"github.com/ginkgoch/stress-test/pkg/client/statistics"
 	"github.com/ginkgoch/stress-test/pkg/templates"
 	"github.com/spf13/cobra"
-	"go.uber.org/ratelimit"
 )
 
 var (
@@ -17,6 +20,14 @@ var (
 	concurrentCount int
 	requestVerb     string
 	headers         []string
+
+	data        []string
+	dataBinary  string
+	forms       []string
+	contentType string
+	assertions  []string
+
+	scenarioFile string
 )
 
 func init() {
@@ -24,57 +35,177 @@ func init() {
 	curlCmd.PersistentFlags().IntVarP(&concurrentCount, "concurrentCount", "p", 100, "e.g 100")
 	curlCmd.PersistentFlags().StringVarP(&requestVerb, "requestVerb", "v", "GET", "GET|POST|PUT|DELETE")
 	curlCmd.PersistentFlags().StringArrayVarP(&headers, "header", "H", []string{}, "origin=eureka.com")
+	curlCmd.PersistentFlags().StringArrayVarP(&data, "data", "", []string{}, `--data '{"name":"a"}' or --data @body.json, new lines of the file are removed, multiple data are joined with &, templated as the url. Unlike curl there is no -d, it is the shorthand of --debug`)
+	curlCmd.PersistentFlags().StringVarP(&dataBinary, "dataBinary", "", "", "--dataBinary @payload.bin, sends the data or the file as it is")
+	curlCmd.PersistentFlags().StringArrayVarP(&forms, "form", "", []string{}, "--form name=a --form age=@age.txt, sends the url encoded form")
+	curlCmd.PersistentFlags().StringVarP(&contentType, "contentType", "", "", "--contentType <type>, default application/json for json data, application/x-www-form-urlencoded for --data and --form")
+
+	curlCmd.PersistentFlags().StringArrayVarP(&assertions, "assert", "", []string{}, `--assert status:200-299 --assert 'json:$.success=true', also contains:<text>, regex:<pattern>, header:<name>[~<pattern>], maxTime:500ms, minSize:<bytes>, maxSize:<bytes>`)
+
+	curlCmd.PersistentFlags().StringVarP(&scenarioFile, "scenario", "", "", "--scenario login.json [baseUrl], runs the steps of the scenario as one iteration, every step is a category")
 
 	rootCmd.AddCommand(curlCmd)
 }
 
 var curlCmd = &cobra.Command{
-	Use:     "curl <url>",
-	Short:   "Curl an url",
-	Long:    `Curl an url`,
-	Args:    cobra.MinimumNArgs(1),
-	Example: `stress-test curl http://localhost:3000/version -c 10000 -p 100 -H origin=moblab.com -H authorization="bearer abc" -k f`,
+	Use:   "curl <url> | curl --scenario <file> [baseUrl]",
+	Short: "Curl an url",
+	Long: `Curl an url. The url, header values and --data are templates evaluated for every request, e.g.
+{{randInt 1 100000}}, {{randString 8}}, {{uuid}}, {{timestamp}}, {{now "2006-01-02"}},
+{{.WorkerID}}, {{.Iteration}}, {{.Seq}} and {{.Data.<field>}} of the record fed by --dataFile.
+The body flags follow curl, except that curl's -d is spelled --data, -d is the shorthand of --debug`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if scenarioFile != "" {
+			return cobra.MaximumNArgs(1)(cmd, args)
+		}
+
+		return cobra.MinimumNArgs(1)(cmd, args)
+	},
+	Example: `stress-test curl http://localhost:3000/version -c 10000 -p 100 -H origin=moblab.com -H authorization="bearer abc" -k f
+stress-test curl http://localhost:3000/version --duration 10m -p 100
+stress-test curl http://localhost:3000/version --stages "2m:200,10m:200,30s:1000,1m:0"
+stress-test curl http://localhost:3000/users --data @user.json
+stress-test curl http://localhost:3000/signIn --form name=a --form password=b
+stress-test curl 'http://localhost:3000/users/{{randInt 1 100000}}' -H 'x-request-id={{uuid}}'
+stress-test curl http://localhost:3000/signIn --data '{"name":"{{.Data.name}}"}' --dataFile users.csv --dataOrder partitioned --dataPolicy stop
+stress-test curl http://localhost:3000/users/1 --assert status:200 --assert 'json:$.success=true' --assert maxTime:500ms
+stress-test curl --scenario login.json http://localhost:3000 --dataFile users.csv`,
 	Run: func(cmd *cobra.Command, args []string) {
 		httpClient := NewHttpClient(ParseBool(keepAlive))
 
+		if scenarioFile != "" {
+			runScenarioCommand(cmd, args, httpClient)
+			return
+		}
+
+		requestTemplate := newCurlTemplate(cmd, args[0])
+
 		if debug {
-			runDebugTest(args, httpClient)
+			runDebugTest(requestTemplate, httpClient)
 		} else {
-			runStressTest(args, httpClient)
+			checks, err := templates.ParseAssertions(assertions)
+			if err != nil {
+				log.Fatalln(err)
+			}
+
+			st := runRequestTest(NewInterruptContext(), "curl", requestTemplate, checks, httpClient)
+			WriteSummary(cmd, args, args[0], st)
+			CheckThresholds(st)
 		}
 	},
 }
 
-func runStressTest(args []string, httpClient *http.Client) {
-	s := client.NewStressClientWithConcurrentNumber(requestCount, concurrentCount)
+// runRequestTest sends the requests of the template as tasks of category name
+func runRequestTest(ctx context.Context, name string, requestTemplate *templates.RequestTemplate, checks []*templates.Assertion, httpClient *http.Client) *statistics.ResultStatistics {
+	s := NewStressClient(requestCount, concurrentCount)
+	requestTemplate.Feeder = NewFeeder(s.MaxThreads())
+	taskFunc := newRequestTask(requestTemplate, checks, httpClient)
 
-	var rateLimiter ratelimit.Limiter
-	if limit > 0 {
-		rateLimiter = ratelimit.New(limit)
+	s.Header()
+	if s.Rate > 0 {
+		return s.RunWithArrivalRate(ctx, name, taskFunc)
 	}
 
-	s.Header()
-	s.RunSingleTaskWithRateLimiter("curl", rateLimiter, func() error {
-		request, _ := http.NewRequest(requestVerb, args[0], nil)
+	return s.RunSingleTaskWithRateLimiter(ctx, name, nil, taskFunc)
+}
+
+// newRequestTask returns the task sending a request of the template and checking the response
+func newRequestTask(requestTemplate *templates.RequestTemplate, checks []*templates.Assertion, httpClient *http.Client) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		request, err := requestTemplate.NewRequest(ctx)
+		if err != nil {
+			return err
+		}
+
+		return templates.HttpCheck(request, httpClient, checks)
+	}
+}
+
+// newCurlTemplate creates the template of the curl requests, a request with body is sent by POST unless -v is set
+func newCurlTemplate(cmd *cobra.Command, url string) *templates.RequestTemplate {
+	body, bodyType := loadRequestBody()
+	if body != nil && !cmd.Flags().Changed("requestVerb") {
+		requestVerb = http.MethodPost
+	}
 
-		if len(headers) > 0 {
-			for _, header := range headers {
-				segs := strings.Split(header, "=")
-				request.Header.Set(segs[0], segs[1])
+	requestTemplate, err := templates.NewRequestTemplate(requestVerb, url, headers, body, len(data) > 0)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	requestTemplate.ContentType = bodyType
+	return requestTemplate
+}
+
+// loadRequestBody reads the body of --data, --dataBinary or --form once, the body is nil when none is set
+func loadRequestBody() (body []byte, bodyType string) {
+	switch {
+	case dataBinary != "":
+		body = readDataFile(dataBinary)
+		bodyType = "application/octet-stream"
+	case len(data) > 0:
+		var segs [][]byte
+		for _, d := range data {
+			seg := readDataFile(d)
+			if strings.HasPrefix(d, "@") {
+				seg = bytes.ReplaceAll(bytes.ReplaceAll(seg, []byte("\r"), nil), []byte("\n"), nil)
+			}
+
+			segs = append(segs, seg)
+		}
+
+		body = bytes.Join(segs, []byte("&"))
+		bodyType = "application/x-www-form-urlencoded"
+		if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+			bodyType = "application/json"
+		}
+	case len(forms) > 0:
+		values := url.Values{}
+		for _, form := range forms {
+			kv := strings.SplitN(form, "=", 2)
+			if len(kv) != 2 {
+				log.Fatalf("invalid form <%s>, expected name=value\n", form)
 			}
+
+			values.Add(kv[0], string(readDataFile(kv[1])))
 		}
 
-		err := templates.HttpGet(request, httpClient)
-		return err
-	})
+		body = []byte(values.Encode())
+		bodyType = "application/x-www-form-urlencoded"
+	}
+
+	if contentType != "" {
+		bodyType = contentType
+	}
+
+	return
+}
+
+// readDataFile returns the content of the file when value starts with @, otherwise value itself
+func readDataFile(value string) []byte {
+	if !strings.HasPrefix(value, "@") {
+		return []byte(value)
+	}
+
+	content, err := ioutil.ReadFile(value[1:])
+	if err != nil {
+		log.Fatalf("read data file failed - %v\n", err)
+	}
+
+	return content
 }
 
-func runDebugTest(args []string, httpClient *http.Client) {
-	request, _ := http.NewRequest(requestVerb, args[0], nil)
-	data, err := templates.SendRequest(request, httpClient)
+func runDebugTest(requestTemplate *templates.RequestTemplate, httpClient *http.Client) {
+	requestTemplate.Feeder = NewFeeder(1)
+	request, err := requestTemplate.NewRequest(context.Background())
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	content, err := templates.SendRequest(request, httpClient)
 	if err != nil {
 		log.Fatalln(err)
 	}
 
-	fmt.Println(string(data))
+	fmt.Println(string(content))
 }
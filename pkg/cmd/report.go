@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/ginkgoch/stress-test/pkg/client/sink"
+	"github.com/ginkgoch/stress-test/pkg/client/statistics"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	reportCmd.Flags().StringVarP(&htmlOutput, "html", "", "", "--html report.html, same as --htmlOut")
+
+	rootCmd.AddCommand(reportCmd)
+}
+
+var reportCmd = &cobra.Command{
+	Use:   "report <file>",
+	Short: "Rebuild the statistics from a raw result file",
+	Long:  `Rebuild the statistics from a raw result file written by --rawOut, including the timeline, percentiles, categories and errors`,
+	Args:  cobra.ExactArgs(1),
+	Example: `stress-test report results.jsonl --out summary.json
+stress-test report results.jsonl --html report.html
+stress-test report results.jsonl --threshold "p95<300ms" --threshold "error_rate<1%"`,
+	Run: func(cmd *cobra.Command, args []string) {
+		st := LoadRawResults(args[0])
+
+		reporter := NewReporter()
+		if table, ok := reporter.(statistics.TableReporter); ok {
+			table.PrintTimeline(st)
+		}
+
+		reporter.Start(st)
+		reporter.Finish(st)
+
+		WriteSummary(cmd, args, args[0], st)
+		CheckThresholds(st)
+	},
+}
+
+// LoadRawResults rebuilds the statistics from the raw result file
+func LoadRawResults(filepath string) *statistics.ResultStatistics {
+	st := statistics.NewResultStatistics(0)
+	st.TimeWindow = nil
+
+	if err := sink.ReadJSONL(filepath, st.AppendRecorded); err != nil {
+		log.Fatalf("read raw results <%s> failed - %v\n", filepath, err)
+	}
+
+	st.Timeline.Seal()
+	st.OpenModel = st.DroppedNum > 0 || st.LateNum > 0
+	return st
+}
@@ -3,32 +3,106 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/ginkgoch/stress-test/pkg/client/feeder"
 	"github.com/ginkgoch/stress-test/pkg/client/statistics"
 	"github.com/ginkgoch/stress-test/pkg/log"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 var (
 	debug     bool
 	keepAlive string
 	limit     int
+	duration  time.Duration
+
+	arrivalRate      float64
+	poisson          bool
+	maxConcurrentNum int
+
+	stages     string
+	stagesFile string
+
+	outputs    []string
+	rawOutput  string
+	htmlOutput string
+
+	metricsAddr string
+	influxUrl   string
+	statsdAddr  string
+	pushTags    string
+	runId       string
+	commandName string
+
+	dataFile   string
+	dataOrder  string
+	dataPolicy string
+
+	outputFormat string
+	locale       string
+
+	thresholds  []string
+	abortOnFail bool
+	abortDelay  time.Duration
 )
 
 var rootCmd = &cobra.Command{
 	Use:   "stress-test",
 	Short: "stress-test provides a concurrent way of doing one task",
 	Long:  `stress-test provides a concurrent way of doing one task with specific number, metrics will automatically printed in the terminal`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		commandName = cmd.Name()
+	},
 }
 
 func init() {
-	rootCmd.PersistentFlags().IntVarP(&limit, "limit", "l", 500, "-l <limit>, default 500")
+	rootCmd.SetGlobalNormalizationFunc(camelCaseFlags)
+
+	rootCmd.PersistentFlags().IntVarP(&limit, "limit", "l", 0, "-l <limit>, task(s) per second shared by all threads, default 0 as unlimited")
+	rootCmd.PersistentFlags().DurationVarP(&duration, "duration", "", 0, "--duration <duration>, e.g. 10m, keeps running until timeout instead of a fixed task count")
+	rootCmd.PersistentFlags().Float64VarP(&arrivalRate, "rate", "", 0, "--rate <tasks per second>, open model that starts tasks at the rate no matter how slow the server is")
+	rootCmd.PersistentFlags().BoolVarP(&poisson, "poisson", "", false, "--poisson, arrivals of --rate in poisson distribution, default false")
+	rootCmd.PersistentFlags().IntVarP(&maxConcurrentNum, "maxConcurrent", "", 1000, "--maxConcurrent <threads>, max threads of --rate, default 1000")
+	rootCmd.PersistentFlags().StringVarP(&stages, "stages", "", "", `--stages "2m:200,10m:200,30s:1000,1m:0", <duration>:<target> of threads, or of --rate when it is set`)
+	rootCmd.PersistentFlags().StringVarP(&stagesFile, "stagesFile", "", "", `--stagesFile <file>, stages in json [{"duration": "2m", "target": 200}] or --stages format`)
+	rootCmd.PersistentFlags().StringArrayVarP(&outputs, "out", "", []string{}, "--out summary.json|summary.csv, writes the run summary in json or csv")
+	rootCmd.PersistentFlags().StringVarP(&htmlOutput, "htmlOut", "", "", "--htmlOut report.html, writes an offline html report with charts")
+	rootCmd.PersistentFlags().StringVarP(&rawOutput, "rawOut", "", "", "--rawOut results.jsonl, writes every result as a json line, see the report command")
+	rootCmd.PersistentFlags().StringVarP(&metricsAddr, "metricsAddr", "", "", "--metricsAddr :9102, serves live prometheus metrics on /metrics during the run")
+	rootCmd.PersistentFlags().StringVarP(&influxUrl, "influxUrl", "", "", "--influxUrl http://localhost:8086/write?db=stress, pushes metrics per second in influxdb line protocol, token from $INFLUX_TOKEN")
+	rootCmd.PersistentFlags().StringVarP(&statsdAddr, "statsdAddr", "", "", "--statsdAddr localhost:8125, pushes metrics per second to statsd over udp")
+	rootCmd.PersistentFlags().StringVarP(&pushTags, "tags", "", "", "--tags env=staging,team=game, extra tags of the pushed metrics besides run_id, command, host and category")
+	rootCmd.PersistentFlags().StringVarP(&runId, "runId", "", "", "--runId <id>, run_id tag of the pushed metrics, default the start time")
+	rootCmd.PersistentFlags().StringVarP(&dataFile, "dataFile", "", "", "--dataFile users.csv|users.jsonl|users.json, test data fed to the tasks, csv with the column names in the first line")
+	rootCmd.PersistentFlags().StringVarP(&dataOrder, "dataOrder", "", feeder.OrderSequential, "--dataOrder sequential|random|partitioned, partitioned splits the data by thread, default sequential")
+	rootCmd.PersistentFlags().StringVarP(&dataPolicy, "dataPolicy", "", feeder.PolicyRecycle, "--dataPolicy recycle|stop, stop ends the threads when the data is exhausted, default recycle")
+	rootCmd.PersistentFlags().StringArrayVarP(&thresholds, "threshold", "", []string{}, `--threshold "p95<300ms" --threshold "error_rate<1%" --threshold "start-game:p99<2s", exits with code 2 when any is violated`)
+	rootCmd.PersistentFlags().BoolVarP(&abortOnFail, "abortOnFail", "", false, "--abortOnFail, stops the run once a threshold is violated, checked every second after --abortDelay")
+	rootCmd.PersistentFlags().DurationVarP(&abortDelay, "abortDelay", "", 10*time.Second, "--abortDelay <duration>, warm up time before --abortOnFail checks, default 10s")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "outputFormat", "", statistics.OutputFormatTable, "--output-format table|plain|json|quiet, plain draws ascii tables for ci logs, json prints a progress line every second and the summary line")
+	rootCmd.PersistentFlags().StringVarP(&locale, "locale", "", "", "--locale zh|en, language of the tables, default zh for table and en for plain")
+	rootCmd.PersistentFlags().StringVarP(&statistics.LiveCategory, "category", "", "", "--category <category>, shows the live table of one category or scenario, e.g. start-game")
 	rootCmd.PersistentFlags().IntVarP(&statistics.TimeWindowSizeInSec, "timeWindow", "w", 5, "-w <windowSizeInSec>, default 5 sec")
 	rootCmd.PersistentFlags().StringVarP(&keepAlive, "keepAlive", "k", "true", "true|t|1 or false|f|0")
 	rootCmd.PersistentFlags().BoolVarP(&debug, "debug", "d", false, "-d, default false")
 	rootCmd.PersistentFlags().BoolVarP(&log.EnableLogger, "log", "o", false, "-o, default false")
 }
 
+// camelCaseFlags accepts the flags in kebab case as well, e.g. --output-format for --outputFormat
+func camelCaseFlags(f *pflag.FlagSet, name string) pflag.NormalizedName {
+	parts := strings.Split(name, "-")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+
+	return pflag.NormalizedName(strings.Join(parts, ""))
+}
+
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
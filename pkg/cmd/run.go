@@ -0,0 +1,299 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/ginkgoch/stress-test/pkg/client"
+	"github.com/ginkgoch/stress-test/pkg/client/runner"
+	"github.com/ginkgoch/stress-test/pkg/client/statistics"
+	"github.com/ginkgoch/stress-test/pkg/scenario"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var dryRun bool
+
+func init() {
+	runCmd.Flags().IntVarP(&requestCount, "requestCount", "c", 20000, "e.g 20000")
+	runCmd.Flags().IntVarP(&concurrentCount, "concurrentCount", "p", 100, "e.g 100")
+	runCmd.Flags().BoolVarP(&dryRun, "dryRun", "", false, "--dry-run, validates and prints the resolved plan without sending any request")
+
+	rootCmd.AddCommand(runCmd)
+}
+
+var runCmd = &cobra.Command{
+	Use:   "run <file> [baseUrl]",
+	Short: "Run the load test described by a yaml or json plan",
+	Long: `Run the load test described by a yaml or json plan: the target, a request or the steps, the load,
+the test data, the thresholds and the outputs. A plan of scenarios runs them at the same time, every scenario
+takes a share of the threads, limit and rate by weight unless it sets its own concurrency or rate. ${NAME} and ${NAME:-default} in the file are replaced by
+the environment variables. The settings of the plan are overridden by the environment variables
+STRESS_TEST_<FLAG>, e.g. STRESS_TEST_MAX_CONCURRENT, which are overridden by the flags`,
+	Args: cobra.RangeArgs(1, 2),
+	Example: `stress-test run login.yaml
+stress-test run login.yaml http://staging:3000 --duration 1m
+stress-test run mix.yaml --duration 10m -p 100 --category writes
+STRESS_TEST_CONCURRENT_COUNT=50 stress-test run login.yaml --dry-run`,
+	Run: func(cmd *cobra.Command, args []string) {
+		plan, err := scenario.LoadPlan(args[0])
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		if err = applyPlanFlags(cmd, plan.Flags()); err != nil {
+			log.Fatalln(err)
+		}
+
+		var baseURL string
+		if len(args) > 1 {
+			baseURL = args[1]
+		}
+
+		if err = plan.Compile(baseURL); err != nil {
+			log.Fatalln(err)
+		}
+
+		if dryRun {
+			printPlan(args[0], plan)
+			return
+		}
+
+		httpClient := NewHttpClient(ParseBool(keepAlive))
+
+		if len(plan.Scenarios) > 0 {
+			st := runMixTest(NewInterruptContext(), plan, httpClient)
+			WriteSummary(cmd, args, plan.BaseURL, st)
+			CheckThresholds(st)
+			return
+		}
+
+		if plan.Request == nil {
+			if debug {
+				runScenarioDebug(&plan.Scenario, httpClient)
+				return
+			}
+
+			st := runScenarioTest(NewInterruptContext(), &plan.Scenario, httpClient)
+			WriteSummary(cmd, args, plan.BaseURL, st)
+			CheckThresholds(st)
+			return
+		}
+
+		if debug {
+			runDebugTest(plan.Request.Template(), httpClient)
+			return
+		}
+
+		st := runRequestTest(NewInterruptContext(), plan.Name, plan.Request.Template(), plan.Request.Assertions(), httpClient)
+		WriteSummary(cmd, args, plan.BaseURL, st)
+		CheckThresholds(st)
+	},
+}
+
+// runMixTest runs the scenarios of the plan at the same time, the test data is partitioned by the threads of every scenario
+func runMixTest(ctx context.Context, plan *scenario.Plan, httpClient *http.Client) *statistics.ResultStatistics {
+	s := NewStressClient(requestCount, concurrentCount)
+
+	scenarios := newMixScenarios(plan, httpClient)
+	resolved, err := s.ResolveScenarios(scenarios)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	for i, w := range plan.Scenarios {
+		threads := resolved[i].ConcurrentNum
+		if resolved[i].Rate > 0 {
+			threads = resolved[i].MaxConcurrentNum
+		}
+
+		if w.Request != nil {
+			w.Request.Template().Feeder = NewFeeder(threads)
+		} else {
+			w.Feeder = NewFeeder(threads)
+		}
+	}
+
+	s.ScenariosHeader(resolved)
+	st, err := s.RunScenarios(ctx, scenarios)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	return st
+}
+
+func newMixScenarios(plan *scenario.Plan, httpClient *http.Client) []*client.Scenario {
+	var scenarios []*client.Scenario
+	for _, w := range plan.Scenarios {
+		sc := &client.Scenario{
+			Name:             w.Name,
+			Weight:           w.Weight,
+			ConcurrentNum:    w.Concurrency,
+			Limitation:       w.Limit,
+			Rate:             w.Rate,
+			MaxConcurrentNum: w.MaxConcurrent,
+		}
+
+		if w.Request != nil {
+			sc.TaskFunc = newRequestTask(w.Request.Template(), w.Request.Assertions(), httpClient)
+		} else {
+			steps := &w.Scenario
+			sc.MultiTaskFunc = func(ctx context.Context, ch chan<- *runner.TaskResult) error {
+				return steps.Run(ctx, httpClient, ch)
+			}
+		}
+
+		scenarios = append(scenarios, sc)
+	}
+
+	return scenarios
+}
+
+// applyPlanFlags sets the flags not given in the command line by the environment variables, or else by the plan
+func applyPlanFlags(cmd *cobra.Command, planFlags map[string][]string) error {
+	var err error
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		if err != nil || flag.Changed {
+			return
+		}
+
+		values, ok := planFlags[flag.Name]
+		env, found := os.LookupEnv(envName(flag.Name))
+		slice, isSlice := flag.Value.(pflag.SliceValue)
+
+		switch {
+		case found && isSlice:
+			values = strings.Split(env, ",")
+		case found:
+			// a scalar value may have commas, e.g. --stages, it is set as it is
+			values = []string{env}
+		case !ok:
+			return
+		}
+
+		if isSlice {
+			err = slice.Replace(values)
+		} else {
+			err = flag.Value.Set(strings.Join(values, ","))
+		}
+
+		if err != nil {
+			err = fmt.Errorf("invalid %s <%s> - %v", flag.Name, strings.Join(values, ","), err)
+		}
+	})
+
+	return err
+}
+
+// envName returns the environment variable of the flag, e.g. STRESS_TEST_MAX_CONCURRENT of maxConcurrent
+func envName(flagName string) string {
+	var b strings.Builder
+	b.WriteString("STRESS_TEST_")
+	for _, r := range flagName {
+		if unicode.IsUpper(r) {
+			b.WriteByte('_')
+		}
+
+		b.WriteRune(unicode.ToUpper(r))
+	}
+
+	return b.String()
+}
+
+// printPlan validates the resolved settings and prints them, no request is sent
+func printPlan(filepath string, plan *scenario.Plan) {
+	fmt.Printf("plan: %s (%s)\n", plan.Name, filepath)
+	fmt.Printf("target: %s\n", RedactURL(plan.BaseURL))
+
+	s := newLoadClient(requestCount, concurrentCount)
+	if len(plan.Scenarios) > 0 {
+		for _, w := range plan.Scenarios {
+			fmt.Printf("\nscenario: %s\n", w.Name)
+			printSteps(&w.Scenario, w.Request)
+		}
+
+		resolved, err := s.ResolveScenarios(newMixScenarios(plan, nil))
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		fmt.Println()
+		fmt.Print("load: ")
+		s.ScenariosHeader(resolved)
+	} else {
+		printSteps(&plan.Scenario, plan.Request)
+
+		if s.Rate > 0 && plan.Request == nil {
+			log.Fatal("rate is not supported with steps")
+		}
+
+		fmt.Println()
+		fmt.Print("load: ")
+		s.Header()
+	}
+
+	if f := NewFeeder(s.MaxThreads()); f != nil {
+		fmt.Printf("data: %d record(s) of %s, %s order, %s when exhausted\n", len(f.Records), dataFile, f.Order, f.Policy)
+	}
+
+	for _, t := range loadThresholds() {
+		fmt.Printf("threshold: %s\n", t.Expr)
+	}
+
+	files := append([]string{}, outputs...)
+	for _, file := range []string{htmlOutput, rawOutput} {
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+
+	if len(files) > 0 {
+		fmt.Printf("outputs: %s\n", strings.Join(files, ", "))
+	}
+
+	fmt.Println()
+	fmt.Println("plan is valid, no request is sent in dry run")
+}
+
+func printSteps(sc *scenario.Scenario, request *scenario.Step) {
+	if request != nil {
+		fmt.Println("request:")
+		printStep(1, request)
+		return
+	}
+
+	fmt.Printf("steps: %d\n", len(sc.Steps))
+	for i, step := range sc.Steps {
+		printStep(i+1, step)
+	}
+}
+
+func printStep(n int, step *scenario.Step) {
+	t := step.Template()
+	fmt.Printf("  %d. %s %s %s\n", n, step.Name, t.Method, RedactURL(t.URL.String()))
+
+	for _, header := range t.Headers {
+		fmt.Printf("     header: %s\n", RedactHeader(header))
+	}
+
+	for _, assertion := range step.Assertions() {
+		fmt.Printf("     assert: %s\n", assertion.Expr)
+	}
+
+	var names []string
+	for name := range step.Extract {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Printf("     extract: %s=%s\n", name, step.Extract[name])
+	}
+}
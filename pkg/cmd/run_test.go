@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestApplyPlanFlags(t *testing.T) {
+	cmd := &cobra.Command{Use: "run"}
+	var profile, method string
+	var headers []string
+	cmd.Flags().StringVar(&profile, "stages", "", "")
+	cmd.Flags().StringVar(&method, "method", "", "")
+	cmd.Flags().StringArrayVarP(&headers, "header", "H", nil, "")
+
+	os.Setenv("STRESS_TEST_STAGES", "1m:10,2m:20")
+	os.Setenv("STRESS_TEST_HEADER", "a=1,b=2")
+	defer os.Unsetenv("STRESS_TEST_STAGES")
+	defer os.Unsetenv("STRESS_TEST_HEADER")
+
+	err := applyPlanFlags(cmd, map[string][]string{
+		"stages": {"30s:1"},
+		"method": {"POST"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if profile != "1m:10,2m:20" {
+		t.Errorf("stages is %q", profile)
+	}
+
+	if method != "POST" {
+		t.Errorf("method is %q", method)
+	}
+
+	if !reflect.DeepEqual(headers, []string{"a=1", "b=2"}) {
+		t.Errorf("headers are %q", headers)
+	}
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/ginkgoch/stress-test/pkg/client/runner"
+	"github.com/ginkgoch/stress-test/pkg/client/statistics"
+	"github.com/ginkgoch/stress-test/pkg/scenario"
+	"github.com/spf13/cobra"
+)
+
+func runScenarioCommand(cmd *cobra.Command, args []string, httpClient *http.Client) {
+	var baseURL string
+	if len(args) > 0 {
+		baseURL = args[0]
+	}
+
+	sc, err := scenario.Load(scenarioFile)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	if err = sc.Compile(baseURL); err != nil {
+		log.Fatalln(err)
+	}
+
+	if debug {
+		runScenarioDebug(sc, httpClient)
+		return
+	}
+
+	st := runScenarioTest(NewInterruptContext(), sc, httpClient)
+	WriteSummary(cmd, args, sc.BaseURL, st)
+	CheckThresholds(st)
+}
+
+func runScenarioTest(ctx context.Context, sc *scenario.Scenario, httpClient *http.Client) *statistics.ResultStatistics {
+	s := NewStressClient(requestCount, concurrentCount)
+	if s.Rate > 0 {
+		log.Fatal("--rate is not supported with --scenario")
+	}
+
+	sc.Feeder = NewFeeder(s.MaxThreads())
+
+	s.Header()
+	return s.RunMultiTasksWithRateLimiter(ctx, sc.Name, nil, func(ctx context.Context, ch chan<- *runner.TaskResult) error {
+		return sc.Run(ctx, httpClient, ch)
+	})
+}
+
+// runScenarioDebug runs one iteration and prints the result of every step
+func runScenarioDebug(sc *scenario.Scenario, httpClient *http.Client) {
+	sc.Feeder = NewFeeder(1)
+
+	ch := make(chan *runner.TaskResult, len(sc.Steps))
+	err := sc.Run(context.Background(), httpClient, ch)
+	close(ch)
+
+	for r := range ch {
+		fmt.Printf("debug - %s success: %v, %d ms, %v\n", r.Category, r.Success, r.ProcessTime/1e6, r.Extra)
+		if !r.Success {
+			fmt.Printf("debug - %s error: %s\n", r.Category, r.Err)
+		}
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -1,9 +1,26 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"log"
 	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
+
+	"github.com/ginkgoch/stress-test/pkg/client"
+	"github.com/ginkgoch/stress-test/pkg/client/feeder"
+	"github.com/ginkgoch/stress-test/pkg/client/report"
+	"github.com/ginkgoch/stress-test/pkg/client/sink"
+	"github.com/ginkgoch/stress-test/pkg/client/statistics"
+	"github.com/ginkgoch/stress-test/pkg/client/threshold"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 var trueFlags []string = []string{"true", "t", "1"}
@@ -65,6 +82,365 @@ func NewHttpClientWithoutRedirect(keepAlive bool) *http.Client {
 	return httpClient
 }
 
+var (
+	// rawSink and metricsSink are created once and kept by the following runs of the process, e.g. talent --endless
+	rawSink, metricsSink *sink.KeptSink
+)
+
+// NewStressClient returns the client of the load flags, with the sinks and thresholds of the flags
+func NewStressClient(number int, concurrent int) *client.StressTestClient {
+	s := newLoadClient(number, concurrent)
+	s.Reporters = []statistics.Reporter{NewReporter()}
+
+	if rawOutput != "" {
+		if rawSink == nil {
+			jsonlSink, err := sink.NewJSONLSink(rawOutput)
+			if err != nil {
+				log.Fatalf("create raw result file failed - %v\n", err)
+			}
+
+			rawSink = &sink.KeptSink{Sink: jsonlSink}
+		}
+
+		s.Sinks = append(s.Sinks, rawSink)
+	}
+
+	if metricsAddr != "" {
+		if metricsSink == nil {
+			prometheusSink, err := sink.NewPrometheusSink(metricsAddr)
+			if err != nil {
+				log.Fatalf("serve metrics failed - %v\n", err)
+			}
+
+			metricsSink = &sink.KeptSink{Sink: prometheusSink}
+		}
+
+		s.Sinks = append(s.Sinks, metricsSink)
+	}
+
+	if checks := loadThresholds(); abortOnFail && len(checks) > 0 {
+		startTime := time.Now()
+		s.AbortWhen = func(st *statistics.ResultStatistics) bool {
+			if time.Since(startTime) < abortDelay {
+				return false
+			}
+
+			// a category may show up later, only the breached thresholds abort the run
+			for _, violation := range threshold.Check(st, checks) {
+				if !violation.Missing {
+					fmt.Fprintln(os.Stderr, "threshold violated, stopping the run -", violation)
+					return true
+				}
+			}
+
+			return false
+		}
+	}
+
+	if influxUrl != "" {
+		pusher := sink.NewInfluxPusher(influxUrl, os.Getenv("INFLUX_TOKEN"))
+		s.Sinks = append(s.Sinks, sink.NewPushSink(pusher, newPushTags()))
+	}
+
+	if statsdAddr != "" {
+		pusher, err := sink.NewStatsDPusher(statsdAddr)
+		if err != nil {
+			log.Fatalf("connect statsd failed - %v\n", err)
+		}
+
+		s.Sinks = append(s.Sinks, sink.NewPushSink(pusher, newPushTags()))
+	}
+
+	return s
+}
+
+// newLoadClient returns the client of the load flags without any sink
+func newLoadClient(number int, concurrent int) *client.StressTestClient {
+	var s *client.StressTestClient
+
+	if duration > 0 {
+		s = client.NewStressClientWithDuration(duration, concurrent)
+	} else {
+		if number < 1 && stages == "" && stagesFile == "" {
+			log.Fatalln("requestCount must be at least 1 without --duration")
+		}
+
+		s = client.NewStressClientWithConcurrentNumber(number, concurrent)
+	}
+
+	if arrivalRate < 0 {
+		log.Fatalf("rate <%v> must not be negative\n", arrivalRate)
+	}
+
+	if limit < 0 {
+		log.Fatalf("limit <%v> must not be negative\n", limit)
+	}
+
+	if arrivalRate > 0 {
+		s.Rate = arrivalRate
+		s.Poisson = poisson
+		s.MaxConcurrentNum = maxConcurrentNum
+	} else if limit > 0 {
+		s.Limitation = limit
+	}
+
+	s.Stages = loadStages()
+	return s
+}
+
+// NewReporter returns the reporter of --outputFormat and --locale
+func NewReporter() statistics.Reporter {
+	reporter, err := statistics.NewReporter(outputFormat, locale)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	return reporter
+}
+
+// infoOut is the writer of the messages around the run, they go to stderr to keep the json lines apart
+func infoOut() io.Writer {
+	if outputFormat == statistics.OutputFormatJSON {
+		return os.Stderr
+	}
+
+	return os.Stdout
+}
+
+// newPushTags returns the tags of the pushed metrics, the run id is kept by the following runs of the process
+func newPushTags() map[string]string {
+	if runId == "" {
+		runId = time.Now().Format("20060102-150405")
+	}
+
+	host, _ := os.Hostname()
+	tags := map[string]string{
+		"run_id":  runId,
+		"command": commandName,
+		"host":    host,
+	}
+
+	for _, pair := range strings.Split(pushTags, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			log.Fatalf("invalid tag <%s>, expected key=value\n", pair)
+		}
+
+		tags[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+
+	return tags
+}
+
+// NewFeeder returns the feeder of --dataFile partitioned by threads, nil when no data file is set
+func NewFeeder(threads int) *feeder.Feeder {
+	if dataFile == "" {
+		return nil
+	}
+
+	f, err := feeder.Load(dataFile, dataOrder, dataPolicy, threads)
+	if err != nil {
+		log.Fatalf("load data file failed - %v\n", err)
+	}
+
+	return f
+}
+
+func loadThresholds() []*threshold.Threshold {
+	result, err := threshold.ParseAll(thresholds)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	return result
+}
+
+// CheckThresholds prints the result of --threshold against st, and exits with code 2 when any is violated
+func CheckThresholds(st *statistics.ResultStatistics) {
+	checks := loadThresholds()
+	if len(checks) == 0 || st == nil {
+		return
+	}
+
+	violations := threshold.Check(st, checks)
+
+	fmt.Fprintln(infoOut())
+	if len(violations) == 0 {
+		fmt.Fprintf(infoOut(), "all %d threshold(s) passed\n", len(checks))
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "%d of %d threshold(s) violated:\n", len(violations), len(checks))
+	for _, violation := range violations {
+		fmt.Fprintln(os.Stderr, "  ✗", violation)
+	}
+
+	os.Exit(2)
+}
+
+func loadStages() []client.Stage {
+	var result []client.Stage
+	var err error
+
+	if stagesFile != "" {
+		result, err = client.LoadStages(stagesFile)
+	} else if stages != "" {
+		result, err = client.ParseStages(stages)
+	}
+
+	if err != nil {
+		log.Fatalf("load stages failed - %v\n", err)
+	}
+
+	return result
+}
+
+// NewRunConfig returns the config of the run saved in the summary, the secrets of the flags and the urls are masked
+func NewRunConfig(cmd *cobra.Command, args []string, target string) *statistics.RunConfig {
+	flags := make(map[string]string)
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		flags[flag.Name] = redactFlag(flag)
+	})
+
+	var redactedArgs []string
+	for _, arg := range args {
+		redactedArgs = append(redactedArgs, RedactURL(arg))
+	}
+
+	return &statistics.RunConfig{
+		Command: cmd.CommandPath(),
+		Args:    redactedArgs,
+		Target:  RedactURL(target),
+		Flags:   flags,
+	}
+}
+
+const redacted = "***"
+
+// secretWords mark the flags, the query parameters and the headers of secret values
+var secretWords = []string{"token", "auth", "password", "secret", "cookie", "key"}
+
+func isSecret(name string) bool {
+	name = strings.ToLower(name)
+	for _, word := range secretWords {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// redactFlag returns the value of flag with the header values and the secrets masked
+func redactFlag(flag *pflag.Flag) string {
+	value := flag.Value.String()
+	if value == "" || value == "[]" {
+		return value
+	}
+
+	if isSecret(flag.Name) {
+		return redacted
+	}
+
+	slice, isSlice := flag.Value.(pflag.SliceValue)
+	if !isSlice {
+		return RedactURL(value)
+	}
+
+	var values []string
+	for _, item := range slice.GetSlice() {
+		if flag.Name == "header" {
+			item = RedactHeader(item)
+		}
+
+		values = append(values, RedactURL(item))
+	}
+
+	return "[" + strings.Join(values, ",") + "]"
+}
+
+// RedactHeader masks the value of a header in name=value or name: value format
+func RedactHeader(header string) string {
+	i := strings.IndexAny(header, "=:")
+	if i < 0 {
+		return header
+	}
+
+	return header[:i+1] + redacted
+}
+
+// RedactURL strips the user info of an url and masks its secret query parameters, other text is returned as it is
+func RedactURL(text string) string {
+	u, err := url.Parse(text)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return text
+	}
+
+	u.User = nil
+
+	query := u.Query()
+	masked := false
+	for key := range query {
+		if isSecret(key) || key == "u" || key == "p" {
+			query.Set(key, redacted)
+			masked = true
+		}
+	}
+
+	if masked {
+		u.RawQuery = query.Encode()
+	}
+
+	return u.String()
+}
+
+// WriteSummary writes the summary of st to the files of --out and --htmlOut
+func WriteSummary(cmd *cobra.Command, args []string, target string, st *statistics.ResultStatistics) {
+	files := append([]string{}, outputs...)
+	if htmlOutput != "" {
+		files = append(files, htmlOutput)
+	}
+
+	if len(files) == 0 || st == nil {
+		return
+	}
+
+	summary := st.Summary()
+	summary.Config = NewRunConfig(cmd, args, target)
+
+	for _, output := range files {
+		if err := report.WriteSummary(output, summary); err != nil {
+			log.Printf("write summary <%s> failed - %v\n", output, err)
+		} else {
+			fmt.Fprintf(infoOut(), "summary saved to %s\n", output)
+		}
+	}
+}
+
+// NewInterruptContext returns a ctx cancelled by the first SIGINT or SIGTERM to finish gracefully,
+// the second signal quits immediately
+func NewInterruptContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		<-signals
+		fmt.Fprintln(os.Stderr, "interrupted, finishing the running tasks, press ctrl-c again to force quit")
+		cancel()
+
+		<-signals
+		os.Exit(1)
+	}()
+
+	return ctx
+}
+
 func TimeIt(handler func()) {
 	startTime := time.Now()
 	handler()
@@ -0,0 +1,29 @@
+//go:build !windows
+// +build !windows
+
+package cmd
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestNewInterruptContext(t *testing.T) {
+	ctx := NewInterruptContext()
+	if ctx.Err() != nil {
+		t.Fatal("ctx is done before the signal")
+	}
+
+	// only the first signal is sent, the second one quits the process
+	if err := syscall.Kill(os.Getpid(), syscall.SIGINT); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("ctx is not cancelled by SIGINT")
+	}
+}
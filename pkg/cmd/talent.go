@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -9,14 +10,13 @@ import (
 	"net/http"
 	"os"
 	"strings"
-	"sync/atomic"
 	"time"
 
 	"github.com/ginkgoch/stress-test/pkg/client"
 	"github.com/ginkgoch/stress-test/pkg/client/runner"
+	"github.com/ginkgoch/stress-test/pkg/client/statistics"
 	"github.com/ginkgoch/stress-test/pkg/talent"
 	"github.com/spf13/cobra"
-	"go.uber.org/ratelimit"
 )
 
 var (
@@ -58,8 +58,8 @@ var toCmd = &cobra.Command{
 	Long:  `Talent optimization test`,
 	Args:  cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		if limit < 1 {
-			log.Fatalf("limit <%v> must greater than 0\n", limit)
+		if useQps && arrivalRate > 0 {
+			log.Fatal("--rate is not supported with --qps")
 		}
 
 		if _, err := os.Stat(filepath); os.IsNotExist(err) {
@@ -88,25 +88,29 @@ var toCmd = &cobra.Command{
 			log.Fatal("no user loaded")
 		}
 
-		fmt.Printf("loaded %v users \n", userLength)
+		fmt.Fprintf(infoOut(), "loaded %v users \n", userLength)
 
 		var httpClient *http.Client
 		if ParseBool(keepAlive) {
 			httpClient = NewHttpClientWithoutRedirect(true)
 		}
 
+		ctx := NewInterruptContext()
+		var st *statistics.ResultStatistics
 		if debug {
-			debugErr := executeSingleTask(userList[0], httpClient, nil)
+			debugErr := executeSingleTask(ctx, userList[0], httpClient, nil)
 			if debugErr != nil {
 				log.Fatal(debugErr)
 			}
 		} else {
 			if endless {
-				for {
-					executeStressTest(userList, httpClient)
+				for ctx.Err() == nil {
+					st = executeStressTest(ctx, userList, httpClient)
+					WriteSummary(cmd, args, talent.ServiceEndpoint, st)
 				}
 			} else {
-				executeStressTest(userList, httpClient)
+				st = executeStressTest(ctx, userList, httpClient)
+				WriteSummary(cmd, args, talent.ServiceEndpoint, st)
 			}
 		}
 
@@ -127,44 +131,78 @@ var toCmd = &cobra.Command{
 
 			ioutil.WriteFile(talentObjFilepath, userJsonData, 0777)
 		}
+
+		CheckThresholds(st)
 	},
 }
 
-func executeStressTest(userList []*talent.TalentObject, httpClient *http.Client) {
-	s := client.NewStressClientWithConcurrentNumber(1, len(userList))
+func executeStressTest(ctx context.Context, userList []*talent.TalentObject, httpClient *http.Client) *statistics.ResultStatistics {
+	s := NewStressClient(1, len(userList))
 
-	var rateLimiter ratelimit.Limiter
+	if httpClient == nil {
+		httpClient = NewHttpClientWithoutRedirect(false)
+	}
 
-	if limit > 0 {
-		rateLimiter = ratelimit.New(limit)
+	user := &client.VirtualUser{
+		Name:        "talent",
+		Client:      httpClient,
+		NoCookieJar: true,
+		Setup: func(ctx context.Context, vu *client.VU) error {
+			vu.State = userList[(vu.ID-1)%len(userList)]
+			return nil
+		},
 	}
 
-	var index uint32 = 0
-	s.Header()
-	if !useQps {
-		s.RunSingleTaskWithRateLimiter("talent", rateLimiter, func() error {
-			tmpIndex := atomic.AddUint32(&index, 1)
-			user := userList[tmpIndex-1]
+	// a thread of the open model serves any arrival, so an arrival checks out a free user for its iteration
+	// instead, a user is never shared by the arrivals running at the same time
+	var freeUsers chan *talent.TalentObject
+	if s.Rate > 0 {
+		freeUsers = make(chan *talent.TalentObject, len(userList))
+		for _, talentObj := range userList {
+			freeUsers <- talentObj
+		}
+	}
 
-			debugErr := executeSingleTask(user, httpClient, nil)
-			return debugErr
-		})
+	withTalent := func(ctx context.Context, vu *client.VU, iterate func(talentObj *talent.TalentObject) error) error {
+		if freeUsers == nil {
+			return iterate(vu.State.(*talent.TalentObject))
+		}
+
+		select {
+		case talentObj := <-freeUsers:
+			defer func() { freeUsers <- talentObj }()
+			return iterate(talentObj)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	if useQps {
+		user.MultiIteration = func(ctx context.Context, vu *client.VU, ch chan<- *runner.TaskResult) error {
+			return withTalent(ctx, vu, func(talentObj *talent.TalentObject) error {
+				return executeSingleTask(ctx, talentObj, vu.Client, ch)
+			})
+		}
 	} else {
-		s.RunMultiTasksWithRateLimiter("talent", rateLimiter, func(ch chan<- *runner.TaskResult) error {
-			tmpIndex := atomic.AddUint32(&index, 1)
-			user := userList[tmpIndex-1]
+		user.Iteration = func(ctx context.Context, vu *client.VU) error {
+			return withTalent(ctx, vu, func(talentObj *talent.TalentObject) error {
+				return executeSingleTask(ctx, talentObj, vu.Client, nil)
+			})
+		}
+	}
 
-			debugErr := executeSingleTask(user, httpClient, ch)
-			return debugErr
-		})
+	s.Header()
+	st, err := s.RunVirtualUsers(ctx, user)
+	if err != nil {
+		log.Fatalln(err)
 	}
+
+	return st
 }
 
-func executeSingleStep(i int, action string, talentObj *talent.TalentObject, ch chan<- *runner.TaskResult, handler func() error) (int, error) {
+func executeSingleStep(ctx context.Context, i int, action string, talentObj *talent.TalentObject, ch chan<- *runner.TaskResult, handler func(ctx context.Context) error) (int, error) {
 	if stage == 0 || stage > i {
-		t1 := time.Now()
-		err := handler()
-		enqueueMetrics(action, &t1, err, ch)
+		err := runTalentStep(ctx, action, ch, handler)
 
 		if err != nil {
 			return i, err
@@ -178,7 +216,7 @@ func executeSingleStep(i int, action string, talentObj *talent.TalentObject, ch
 	return i, nil
 }
 
-func executeSingleTask(user *talent.TalentObject, httpClient *http.Client, ch chan<- *runner.TaskResult) (err error) {
+func executeSingleTask(ctx context.Context, user *talent.TalentObject, httpClient *http.Client, ch chan<- *runner.TaskResult) (err error) {
 	if httpClient == nil {
 		httpClient = NewHttpClientWithoutRedirect(false)
 	}
@@ -186,9 +224,9 @@ func executeSingleTask(user *talent.TalentObject, httpClient *http.Client, ch ch
 	talentObj := user //talent.NewTalentObject()
 
 	if stage == -1 {
-		t1 := time.Now()
-		err = talentObj.Status(httpClient)
-		enqueueMetrics("status", &t1, err, ch)
+		err = runTalentStep(ctx, "status", ch, func(ctx context.Context) error {
+			return talentObj.Status(ctx, httpClient)
+		})
 
 		if err != nil {
 			return err
@@ -201,8 +239,8 @@ func executeSingleTask(user *talent.TalentObject, httpClient *http.Client, ch ch
 
 	i := 0
 	if talentObj.Cookie == nil {
-		if i, err = executeSingleStep(i, "sign-in", talentObj, ch, func() error {
-			return talentObj.SignIn(httpClient)
+		if i, err = executeSingleStep(ctx, i, "sign-in", talentObj, ch, func(ctx context.Context) error {
+			return talentObj.SignIn(ctx, httpClient)
 		}); err != nil {
 			return
 		}
@@ -211,8 +249,8 @@ func executeSingleTask(user *talent.TalentObject, httpClient *http.Client, ch ch
 	}
 
 	if talentObj.UserId == "" {
-		if i, err = executeSingleStep(i, "information", talentObj, ch, func() error {
-			return talentObj.Information(httpClient)
+		if i, err = executeSingleStep(ctx, i, "information", talentObj, ch, func(ctx context.Context) error {
+			return talentObj.Information(ctx, httpClient)
 		}); err != nil {
 			return
 		}
@@ -222,9 +260,9 @@ func executeSingleTask(user *talent.TalentObject, httpClient *http.Client, ch ch
 
 	var currentIndex = i
 	for _, game := range games {
-		if i, err = executeSingleStep(i, "start-game", talentObj, ch, func() error {
-			processDelay()
-			return talentObj.StartGame(game, httpClient)
+		if i, err = executeSingleStep(ctx, i, "start-game", talentObj, ch, func(ctx context.Context) error {
+			processDelay(ctx)
+			return talentObj.StartGame(ctx, game, httpClient)
 		}); err != nil {
 			return
 		}
@@ -240,9 +278,9 @@ func executeSingleTask(user *talent.TalentObject, httpClient *http.Client, ch ch
 			i++
 		}
 
-		if _, err = executeSingleStep(i, "stop-game", talentObj, ch, func() error {
-			processDelay()
-			return talentObj.StopGame(game, httpClient)
+		if _, err = executeSingleStep(ctx, i, "stop-game", talentObj, ch, func(ctx context.Context) error {
+			processDelay(ctx)
+			return talentObj.StopGame(ctx, game, httpClient)
 		}); err != nil {
 			return
 		}
@@ -253,30 +291,25 @@ func executeSingleTask(user *talent.TalentObject, httpClient *http.Client, ch ch
 	return
 }
 
-func processDelay() {
+func processDelay(ctx context.Context) {
 	if delay > 0 {
 		sleeping := delay + rand.Intn(1000)
-		time.Sleep(time.Duration(sleeping) * time.Millisecond)
-	}
-}
 
-func enqueueMetrics(name string, startTime *time.Time, err error, ch chan<- *runner.TaskResult) {
-	endTime := time.Now()
-	d := endTime.Sub(*startTime).Nanoseconds()
+		timer := time.NewTimer(time.Duration(sleeping) * time.Millisecond)
+		defer timer.Stop()
 
-	if ch != nil {
-		errMsg := ""
-		if err != nil {
-			errMsg = err.Error()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
 		}
+	}
+}
 
-		ch <- &runner.TaskResult{
-			Success:     err == nil,
-			ProcessTime: uint64(d),
-			StartTime:   uint64(startTime.UnixNano()),
-			EndTime:     uint64(endTime.UnixNano()),
-			Category:    name,
-			Err:         errMsg,
-		}
+// runTalentStep runs a step as a result of category name into ch, the step only runs without ch, e.g. in --debug
+func runTalentStep(ctx context.Context, name string, ch chan<- *runner.TaskResult, step func(ctx context.Context) error) error {
+	if ch == nil {
+		return step(ctx)
 	}
+
+	return runner.RunStep(ctx, name, ch, step)
 }
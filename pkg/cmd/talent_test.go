@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ginkgoch/stress-test/pkg/client/statistics"
+	"github.com/ginkgoch/stress-test/pkg/talent"
+)
+
+// TestExecuteStressTestWithRate runs more arrivals than users at the same time, run it with -race
+func TestExecuteStressTestWithRate(t *testing.T) {
+	var locker sync.Mutex
+	active := make(map[string]bool)
+	shared := false
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		phone := r.URL.Query().Get("phoneNumber")
+		if cookie, err := r.Cookie("this.sid"); err == nil {
+			phone = cookie.Value
+		}
+
+		locker.Lock()
+		if active[phone] {
+			shared = true
+		}
+		active[phone] = true
+		locker.Unlock()
+
+		defer func() {
+			locker.Lock()
+			delete(active, phone)
+			locker.Unlock()
+		}()
+
+		time.Sleep(5 * time.Millisecond)
+
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/zhilian/login"):
+			http.SetCookie(w, &http.Cookie{Name: "this.sid", Value: phone})
+			fmt.Fprint(w, `{"success": true}`)
+		case strings.HasSuffix(r.URL.Path, "/student/information"):
+			fmt.Fprintf(w, `{"success": true, "user": {"id": "%s"}}`, phone)
+		case strings.Contains(r.URL.Path, "/startGame/"):
+			fmt.Fprint(w, `{"success": true, "data": {"id": "1", "playerId": "1"}}`)
+		default:
+			fmt.Fprint(w, `{"success": true}`)
+		}
+	}))
+	defer server.Close()
+
+	defer func(endpoint string, d time.Duration, rate float64, maxConcurrent int, format string) {
+		talent.ServiceEndpoint, duration, arrivalRate, maxConcurrentNum, outputFormat = endpoint, d, rate, maxConcurrent, format
+	}(talent.ServiceEndpoint, duration, arrivalRate, maxConcurrentNum, outputFormat)
+
+	talent.ServiceEndpoint = server.URL
+	duration, arrivalRate, maxConcurrentNum, outputFormat = 500*time.Millisecond, 200, 50, statistics.OutputFormatQuiet
+
+	userList := []*talent.TalentObject{
+		{SignInConfig: &talent.SignInConfig{PhoneNumber: "1"}},
+		{SignInConfig: &talent.SignInConfig{PhoneNumber: "2"}},
+	}
+
+	st := executeStressTest(context.Background(), userList, server.Client())
+	if st.SuccessNum == 0 || st.FailureNum > 0 {
+		t.Errorf("success %d, failure %d, errors %v", st.SuccessNum, st.FailureNum, st.Errors)
+	}
+
+	if shared {
+		t.Error("a user runs more than one arrival at the same time")
+	}
+
+	for _, talentObj := range userList {
+		if talentObj.UserId != talentObj.SignInConfig.PhoneNumber {
+			t.Errorf("user %s signed in as %s", talentObj.SignInConfig.PhoneNumber, talentObj.UserId)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package scenario
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ginkgoch/stress-test/pkg/client"
+	"gopkg.in/yaml.v2"
+)
+
+// Plan is a load test described by a yaml or json file: the target, a request or the steps,
+// the load profile, the test data, the thresholds and the outputs
+type Plan struct {
+	Scenario
+	// Request is the only request of the plan, when there is no step
+	Request *Step `json:"request"`
+	// Scenarios run at the same time in one run instead of the request or the steps
+	Scenarios  []*Workload `json:"scenarios"`
+	Load       LoadProfile `json:"load"`
+	Data       Data        `json:"data"`
+	Thresholds []string    `json:"thresholds"`
+	Outputs    Outputs     `json:"outputs"`
+	// Metrics are the live metrics endpoints
+	Metrics Metrics `json:"metrics"`
+}
+
+// Workload is one scenario of a mixed plan with a request or the steps, it takes a share of the load by weight
+// unless it sets its own concurrency or rate, see client.Scenario
+type Workload struct {
+	Scenario
+	Request       *Step   `json:"request"`
+	Weight        float64 `json:"weight"`
+	Concurrency   int     `json:"concurrency"`
+	Limit         int     `json:"limit"`
+	Rate          float64 `json:"rate"`
+	MaxConcurrent int     `json:"maxConcurrent"`
+}
+
+// LoadProfile is the load of the plan, the fields work as the flags of the same names
+type LoadProfile struct {
+	Requests      int            `json:"requests"`
+	Concurrency   int            `json:"concurrency"`
+	Limit         *int           `json:"limit"`
+	Duration      string         `json:"duration"`
+	Rate          float64        `json:"rate"`
+	Poisson       bool           `json:"poisson"`
+	MaxConcurrent int            `json:"maxConcurrent"`
+	Stages        []client.Stage `json:"stages"`
+	KeepAlive     *bool          `json:"keepAlive"`
+	AbortOnFail   bool           `json:"abortOnFail"`
+	AbortDelay    string         `json:"abortDelay"`
+}
+
+type Data struct {
+	File   string `json:"file"`
+	Order  string `json:"order"`
+	Policy string `json:"policy"`
+}
+
+type Outputs struct {
+	Out  []string `json:"out"`
+	HTML string   `json:"html"`
+	Raw  string   `json:"raw"`
+}
+
+type Metrics struct {
+	Addr   string `json:"addr"`
+	Influx string `json:"influx"`
+	StatsD string `json:"statsd"`
+	Tags   string `json:"tags"`
+}
+
+// LoadPlan reads the plan of a .yaml, .yml or .json file, ${NAME} and ${NAME:-default} are replaced by the environment variables
+func LoadPlan(filepath string) (*Plan, error) {
+	data, err := ioutil.ReadFile(filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	data = []byte(expandEnv(string(data)))
+
+	switch ext := path.Ext(filepath); ext {
+	case ".yaml", ".yml":
+		if data, err = yamlToJSON(data); err != nil {
+			return nil, fmt.Errorf("read plan <%s> failed - %v", filepath, err)
+		}
+	case ".json":
+	default:
+		return nil, fmt.Errorf("plan format <%s> is not supported, use .yaml, .yml or .json", ext)
+	}
+
+	p := new(Plan)
+	if err = json.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("read plan <%s> failed - %v", filepath, err)
+	}
+
+	return p, nil
+}
+
+// Compile compiles the steps, the request or the scenarios, see Scenario.Compile
+func (p *Plan) Compile(baseURL string) error {
+	if p.Name == "" {
+		p.Name = "run"
+	}
+
+	if len(p.Scenarios) == 0 {
+		return compile(&p.Scenario, p.Request, baseURL)
+	}
+
+	if p.Request != nil || len(p.Steps) > 0 {
+		return fmt.Errorf("plan <%s> has scenarios besides the request or steps", p.Name)
+	}
+
+	if baseURL != "" {
+		p.BaseURL = baseURL
+	}
+
+	for _, w := range p.Scenarios {
+		if w.BaseURL == "" || baseURL != "" {
+			w.BaseURL = p.BaseURL
+		}
+
+		if err := compile(&w.Scenario, w.Request, ""); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// compile compiles the steps of sc, or the request as the only step
+func compile(sc *Scenario, request *Step, baseURL string) error {
+	if request == nil {
+		return sc.Compile(baseURL)
+	}
+
+	if len(sc.Steps) > 0 {
+		return fmt.Errorf("scenario <%s> has both request and steps", sc.Name)
+	}
+
+	if baseURL != "" {
+		sc.BaseURL = baseURL
+	}
+
+	if request.Name == "" {
+		request.Name = sc.Name
+	}
+
+	return request.compile(sc.BaseURL)
+}
+
+// Flags returns the flag values of the plan by flag name, the empty settings are skipped
+func (p *Plan) Flags() map[string][]string {
+	flags := make(map[string][]string)
+	set := func(name string, value string) {
+		if value != "" {
+			flags[name] = []string{value}
+		}
+	}
+
+	l := p.Load
+	if l.Requests > 0 {
+		set("requestCount", strconv.Itoa(l.Requests))
+	}
+	if l.Concurrency > 0 {
+		set("concurrentCount", strconv.Itoa(l.Concurrency))
+	}
+	if l.Limit != nil {
+		set("limit", strconv.Itoa(*l.Limit))
+	}
+	if l.Rate > 0 {
+		set("rate", strconv.FormatFloat(l.Rate, 'f', -1, 64))
+	}
+	if l.Poisson {
+		set("poisson", "true")
+	}
+	if l.MaxConcurrent > 0 {
+		set("maxConcurrent", strconv.Itoa(l.MaxConcurrent))
+	}
+	if l.KeepAlive != nil {
+		set("keepAlive", strconv.FormatBool(*l.KeepAlive))
+	}
+	if l.AbortOnFail {
+		set("abortOnFail", "true")
+	}
+	set("duration", l.Duration)
+	set("abortDelay", l.AbortDelay)
+
+	var stages []string
+	for i := range l.Stages {
+		stages = append(stages, l.Stages[i].String())
+	}
+	set("stages", strings.Join(stages, ","))
+
+	set("dataFile", p.Data.File)
+	set("dataOrder", p.Data.Order)
+	set("dataPolicy", p.Data.Policy)
+
+	if len(p.Thresholds) > 0 {
+		flags["threshold"] = p.Thresholds
+	}
+
+	if len(p.Outputs.Out) > 0 {
+		flags["out"] = p.Outputs.Out
+	}
+	set("htmlOut", p.Outputs.HTML)
+	set("rawOut", p.Outputs.Raw)
+
+	set("metricsAddr", p.Metrics.Addr)
+	set("influxUrl", p.Metrics.Influx)
+	set("statsdAddr", p.Metrics.StatsD)
+	set("tags", p.Metrics.Tags)
+
+	return flags
+}
+
+var envPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// expandEnv replaces ${NAME} and ${NAME:-default}, {{ }} of the templates are kept
+func expandEnv(text string) string {
+	return envPattern.ReplaceAllStringFunc(text, func(match string) string {
+		groups := envPattern.FindStringSubmatch(match)
+		if value, ok := os.LookupEnv(groups[1]); ok {
+			return value
+		}
+
+		return groups[3]
+	})
+}
+
+// yamlToJSON converts yaml to json, so the plan is decoded by the json tags in both formats
+func yamlToJSON(data []byte) ([]byte, error) {
+	var value interface{}
+	if err := yaml.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(jsonCompatible(value))
+}
+
+// jsonCompatible converts the map[interface{}]interface{} of yaml to map[string]interface{}
+func jsonCompatible(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{})
+		for key, item := range v {
+			result[fmt.Sprint(key)] = jsonCompatible(item)
+		}
+
+		return result
+	case []interface{}:
+		for i, item := range v {
+			v[i] = jsonCompatible(item)
+		}
+
+		return v
+	default:
+		return v
+	}
+}
@@ -0,0 +1,203 @@
+package scenario
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"sort"
+	"strings"
+
+	"github.com/ginkgoch/stress-test/pkg/client/feeder"
+	"github.com/ginkgoch/stress-test/pkg/client/runner"
+	"github.com/ginkgoch/stress-test/pkg/templates"
+)
+
+// Scenario is an ordered list of http steps run as one iteration, a step can use the values
+// extracted by the previous steps as {{.Vars.<name>}}. Every step is a category of the statistics.
+type Scenario struct {
+	Name    string  `json:"name"`
+	BaseURL string  `json:"baseUrl"`
+	Steps   []*Step `json:"steps"`
+	// Feeder feeds one record to all steps of an iteration as {{.Data.<field>}}, it is optional
+	Feeder *feeder.Feeder `json:"-"`
+
+	seq uint64
+}
+
+// Step is one request of the scenario, the url, header values and body are templates
+type Step struct {
+	Name    string            `json:"name"`
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+	// Body is sent as it is when it is a string, otherwise as json
+	Body interface{} `json:"body"`
+	// Assert are the assertions of the response, see templates.ParseAssertion
+	Assert []string `json:"assert"`
+	// Extract are the values to extract from the response by name, see templates.ParseExtractor
+	Extract map[string]string `json:"extract"`
+
+	request    *templates.RequestTemplate
+	assertions []*templates.Assertion
+	extractors map[string]*templates.Extractor
+}
+
+// Load reads the scenario of a json file
+func Load(filepath string) (*Scenario, error) {
+	data, err := ioutil.ReadFile(filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	s := new(Scenario)
+	if err = json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("read scenario <%s> failed - %v", filepath, err)
+	}
+
+	return s, nil
+}
+
+// Compile parses the templates, assertions and extractors of the steps, baseURL overrides the one of the scenario when it is not empty
+func (s *Scenario) Compile(baseURL string) error {
+	if baseURL != "" {
+		s.BaseURL = baseURL
+	}
+
+	if len(s.Steps) == 0 {
+		return fmt.Errorf("no step in scenario <%s>", s.Name)
+	}
+
+	for i, step := range s.Steps {
+		if step.Name == "" {
+			step.Name = fmt.Sprintf("step-%d", i+1)
+		}
+
+		if err := step.compile(s.BaseURL); err != nil {
+			return fmt.Errorf("invalid step <%s> - %v", step.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func (step *Step) compile(baseURL string) error {
+	method := strings.ToUpper(step.Method)
+	if method == "" {
+		method = http.MethodGet
+		if step.Body != nil {
+			method = http.MethodPost
+		}
+	}
+
+	url := step.URL
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		url = strings.TrimSuffix(baseURL, "/") + "/" + strings.TrimPrefix(url, "/")
+	}
+
+	var names []string
+	for name := range step.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var headers []string
+	for _, name := range names {
+		headers = append(headers, name+"="+step.Headers[name])
+	}
+
+	var body []byte
+	contentType := ""
+	switch b := step.Body.(type) {
+	case nil:
+	case string:
+		body = []byte(b)
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			return err
+		}
+
+		body = data
+		contentType = "application/json"
+	}
+
+	var err error
+	if step.request, err = templates.NewRequestTemplate(method, url, headers, body, true); err != nil {
+		return err
+	}
+
+	step.request.ContentType = contentType
+
+	if step.assertions, err = templates.ParseAssertions(step.Assert); err != nil {
+		return err
+	}
+
+	step.extractors = make(map[string]*templates.Extractor)
+	for name, expr := range step.Extract {
+		if step.extractors[name], err = templates.ParseExtractor(expr); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Template returns the request template of the compiled step
+func (step *Step) Template() *templates.RequestTemplate {
+	return step.request
+}
+
+// Assertions returns the assertions of the compiled step
+func (step *Step) Assertions() []*templates.Assertion {
+	return step.assertions
+}
+
+// Run runs the steps in order as one iteration with its own cookie jar, the results of the steps are sent to ch.
+// It stops at the first failed step.
+func (s *Scenario) Run(ctx context.Context, httpClient *http.Client, ch chan<- *runner.TaskResult) error {
+	vars, err := templates.NewVars(ctx, &s.seq, s.Feeder)
+	if err != nil {
+		return err
+	}
+
+	vars.Vars = make(map[string]string)
+
+	jar, _ := cookiejar.New(nil)
+	client := *httpClient
+	client.Jar = jar
+
+	for _, step := range s.Steps {
+		err = runner.RunStep(ctx, step.Name, ch, func(ctx context.Context) error {
+			return step.run(ctx, &client, vars)
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (step *Step) run(ctx context.Context, httpClient *http.Client, vars *templates.Vars) error {
+	request, err := step.request.Render(ctx, vars)
+	if err != nil {
+		return err
+	}
+
+	res, err := templates.HttpDo(request, httpClient, step.assertions)
+	if err != nil {
+		return err
+	}
+
+	for name, extractor := range step.extractors {
+		if vars.Vars[name], err = extractor.Extract(res); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
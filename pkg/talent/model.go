@@ -50,7 +50,7 @@ func (t *TalentObject) String() string {
 }
 
 type Information struct {
-	Success bool `json:"success"`
+	Success *bool `json:"success"`
 	User    struct {
 		Name        string `json:"name"`
 		PhoneNumber string `json:"phoneNumber"`
@@ -67,7 +67,7 @@ type Information struct {
 // }
 
 type StartGameData struct {
-	Success bool `json:"success"`
+	Success *bool `json:"success"`
 	Data    struct {
 		ID       string `json:"id"`
 		PlayerID string `json:"playerId"`
@@ -1,6 +1,7 @@
 package talent
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -22,14 +23,17 @@ const (
 
 var (
 	ServiceEndpoint string
+
+	// errUnsuccessful is returned when the api responds 200 with {"success": false}
+	errUnsuccessful = &templates.AssertionError{Kind: templates.AssertJSON, Assertion: "json:success=true", Reason: "success is false"}
 )
 
 func NewTalentObject() *TalentObject {
 	return new(TalentObject)
 }
 
-func (talent *TalentObject) Status(httpClient *http.Client) error {
-	request, err := http.NewRequest("GET", talent.formalizeUrl(statusUrl), nil)
+func (talent *TalentObject) Status(ctx context.Context, httpClient *http.Client) error {
+	request, err := http.NewRequestWithContext(ctx, "GET", talent.formalizeUrl(statusUrl), nil)
 	if err != nil {
 		return err
 	}
@@ -38,12 +42,12 @@ func (talent *TalentObject) Status(httpClient *http.Client) error {
 	return err
 }
 
-func (talent *TalentObject) SignIn(httpClient *http.Client) error {
+func (talent *TalentObject) SignIn(ctx context.Context, httpClient *http.Client) error {
 	// if talent.Cookie != nil {
 	// 	return nil
 	// }
 
-	request, err := http.NewRequest("GET", talent.formalizeUrl(signInUrl), nil)
+	request, err := http.NewRequestWithContext(ctx, "GET", talent.formalizeUrl(signInUrl), nil)
 	if err != nil {
 		return err
 	}
@@ -66,7 +70,12 @@ func (talent *TalentObject) SignIn(httpClient *http.Client) error {
 
 	defer res.Body.Close()
 
-	if _, err = templates.ConsumeResponse(res); err != nil {
+	data, err := templates.ConsumeResponse(res)
+	if err != nil {
+		return err
+	}
+
+	if err = checkSuccess(data); err != nil {
 		return err
 	}
 
@@ -80,12 +89,12 @@ func (talent *TalentObject) SignIn(httpClient *http.Client) error {
 	return nil
 }
 
-func (talent *TalentObject) Information(httpClient *http.Client) error {
+func (talent *TalentObject) Information(ctx context.Context, httpClient *http.Client) error {
 	// if talent.UserId != "" {
 	// 	return nil
 	// }
 
-	request, err := http.NewRequest("GET", talent.formalizeUrl(informationUrl), nil)
+	request, err := http.NewRequestWithContext(ctx, "GET", talent.formalizeUrl(informationUrl), nil)
 	request.AddCookie(talent.Cookie)
 	if err != nil {
 		return err
@@ -101,6 +110,10 @@ func (talent *TalentObject) Information(httpClient *http.Client) error {
 		return err
 	}
 
+	if err = checkSuccess(infoData); err != nil {
+		return err
+	}
+
 	info := new(Information)
 	if err = json.Unmarshal(infoData, &info); err != nil {
 		return err
@@ -110,10 +123,10 @@ func (talent *TalentObject) Information(httpClient *http.Client) error {
 	return nil
 }
 
-func (talent *TalentObject) StartGame(gameId string, httpClient *http.Client) error {
+func (talent *TalentObject) StartGame(ctx context.Context, gameId string, httpClient *http.Client) error {
 	relPath := fmt.Sprintf(startGameUrl, talent.UserId, gameId)
 
-	request, err := http.NewRequest("GET", talent.formalizeUrl(relPath), nil)
+	request, err := http.NewRequestWithContext(ctx, "GET", talent.formalizeUrl(relPath), nil)
 	request.Header.Set("Content-Type", "application/json")
 
 	if talent.Cookie != nil {
@@ -133,6 +146,8 @@ func (talent *TalentObject) StartGame(gameId string, httpClient *http.Client) er
 	data, err := templates.ConsumeResponse(res)
 	if err != nil {
 		return err
+	} else if err = checkSuccess(data); err != nil {
+		return err
 	} else {
 		startGameData := new(StartGameData)
 		err = json.Unmarshal(data, startGameData)
@@ -152,10 +167,10 @@ func (talent *TalentObject) StartGame(gameId string, httpClient *http.Client) er
 	return err
 }
 
-func (talent *TalentObject) StopGame(gameId string, httpClient *http.Client) (err error) {
+func (talent *TalentObject) StopGame(ctx context.Context, gameId string, httpClient *http.Client) (err error) {
 	relPath := fmt.Sprintf(finishGameUrl, gameId)
 
-	request, err := http.NewRequest("GET", talent.formalizeUrl(relPath), nil)
+	request, err := http.NewRequestWithContext(ctx, "GET", talent.formalizeUrl(relPath), nil)
 	request.Header.Set("Content-Type", "application/json")
 
 	if talent.Cookie != nil {
@@ -165,8 +180,25 @@ func (talent *TalentObject) StopGame(gameId string, httpClient *http.Client) (er
 		return err
 	}
 
-	err = templates.HttpGet(request, httpClient)
-	return
+	data, err := templates.SendRequest(request, httpClient)
+	if err != nil {
+		return err
+	}
+
+	return checkSuccess(data)
+}
+
+// checkSuccess returns errUnsuccessful when data is json of {"success": false}, a body without success passes
+func checkSuccess(data []byte) error {
+	result := new(struct {
+		Success *bool `json:"success"`
+	})
+
+	if json.Unmarshal(data, result) == nil && result.Success != nil && !*result.Success {
+		return errUnsuccessful
+	}
+
+	return nil
 }
 
 func (talent *TalentObject) PlayGame(gameId string) (err error) {
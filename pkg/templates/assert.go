@@ -0,0 +1,298 @@
+package templates
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	AssertStatus   = "status"
+	AssertContains = "contains"
+	AssertRegex    = "regex"
+	AssertJSON     = "json"
+	AssertHeader   = "header"
+	AssertMaxTime  = "maxTime"
+	AssertMinSize  = "minSize"
+	AssertMaxSize  = "maxSize"
+)
+
+// Assertion is a check of the response, parsed from <kind>:<argument>, see ParseAssertion
+type Assertion struct {
+	Expr string
+	Kind string
+
+	statusRanges [][2]int
+	text         string
+	pattern      *regexp.Regexp
+	path         []string
+	value        *string
+	duration     time.Duration
+	size         int
+}
+
+// AssertionError is returned when the response fails an assertion, its class is assert-<kind>
+type AssertionError struct {
+	Kind      string
+	Assertion string
+	Reason    string
+}
+
+func (e *AssertionError) Error() string {
+	return fmt.Sprintf("assertion <%s> failed - %s", e.Assertion, e.Reason)
+}
+
+func (e *AssertionError) ErrorClass() string {
+	return "assert-" + e.Kind
+}
+
+// ParseAssertion parses the assertions
+//   status:200, status:200-299 or status:200,201,3xx
+//   contains:<text> and regex:<pattern> of the body
+//   json:<path> exists or json:<path>=<value>, e.g. json:$.success=true or json:data.items[0].id
+//   header:<name> exists or header:<name>~<pattern>
+//   maxTime:500ms, minSize:<bytes> and maxSize:<bytes>
+func ParseAssertion(expr string) (*Assertion, error) {
+	segs := strings.SplitN(expr, ":", 2)
+	if len(segs) != 2 {
+		return nil, fmt.Errorf("invalid assertion <%s>, expected <kind>:<argument>", expr)
+	}
+
+	a := &Assertion{Expr: expr, Kind: segs[0]}
+	arg := segs[1]
+
+	var err error
+	switch a.Kind {
+	case AssertStatus:
+		err = a.parseStatus(arg)
+	case AssertContains:
+		a.text = arg
+	case AssertRegex:
+		a.pattern, err = regexp.Compile(arg)
+	case AssertJSON:
+		if i := strings.Index(arg, "="); i >= 0 {
+			value := strings.TrimSpace(arg[i+1:])
+			a.value = &value
+			arg = arg[:i]
+		}
+
+		a.path, err = parseJSONPath(strings.TrimSpace(arg))
+	case AssertHeader:
+		if i := strings.Index(arg, "~"); i >= 0 {
+			a.pattern, err = regexp.Compile(arg[i+1:])
+			arg = arg[:i]
+		}
+
+		a.text = http.CanonicalHeaderKey(strings.TrimSpace(arg))
+	case AssertMaxTime:
+		a.duration, err = time.ParseDuration(arg)
+	case AssertMinSize, AssertMaxSize:
+		a.size, err = strconv.Atoi(arg)
+	default:
+		err = fmt.Errorf("unknown kind <%s>", a.Kind)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid assertion <%s> - %v", expr, err)
+	}
+
+	return a, nil
+}
+
+// ParseAssertions parses the assertions, the first invalid one fails all of them
+func ParseAssertions(exprs []string) ([]*Assertion, error) {
+	var result []*Assertion
+	for _, expr := range exprs {
+		a, err := ParseAssertion(expr)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, a)
+	}
+
+	return result, nil
+}
+
+func (a *Assertion) parseStatus(arg string) error {
+	for _, seg := range strings.Split(arg, ",") {
+		seg = strings.TrimSpace(seg)
+
+		if len(seg) == 3 && strings.HasSuffix(seg, "xx") {
+			n, err := strconv.Atoi(seg[:1])
+			if err != nil {
+				return err
+			}
+
+			a.statusRanges = append(a.statusRanges, [2]int{n * 100, n*100 + 99})
+			continue
+		}
+
+		bounds := strings.SplitN(seg, "-", 2)
+		from, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			return err
+		}
+
+		to := from
+		if len(bounds) == 2 {
+			if to, err = strconv.Atoi(bounds[1]); err != nil {
+				return err
+			}
+		}
+
+		a.statusRanges = append(a.statusRanges, [2]int{from, to})
+	}
+
+	return nil
+}
+
+// Response is what the assertions check, the body is read completely
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Cookies    []*http.Cookie
+	Body       []byte
+	Elapsed    time.Duration
+
+	json       interface{}
+	jsonErr    error
+	jsonParsed bool
+}
+
+// Check returns an AssertionError when res fails the assertion, or StatusError for the status assertion
+func (a *Assertion) Check(res *Response) error {
+	switch a.Kind {
+	case AssertStatus:
+		for _, r := range a.statusRanges {
+			if res.StatusCode >= r[0] && res.StatusCode <= r[1] {
+				return nil
+			}
+		}
+
+		return &StatusError{StatusCode: res.StatusCode}
+	case AssertContains:
+		if !bytes.Contains(res.Body, []byte(a.text)) {
+			return a.fail("body does not contain %q", a.text)
+		}
+	case AssertRegex:
+		if !a.pattern.Match(res.Body) {
+			return a.fail("body does not match")
+		}
+	case AssertJSON:
+		return a.checkJSON(res)
+	case AssertHeader:
+		values, ok := res.Header[a.text]
+		if !ok {
+			return a.fail("header %s is missing", a.text)
+		}
+
+		if a.pattern != nil && !a.pattern.MatchString(strings.Join(values, ", ")) {
+			return a.fail("header %s is %q", a.text, strings.Join(values, ", "))
+		}
+	case AssertMaxTime:
+		if res.Elapsed > a.duration {
+			return a.fail("response took %v", res.Elapsed.Round(time.Millisecond))
+		}
+	case AssertMinSize:
+		if len(res.Body) < a.size {
+			return a.fail("body is %d bytes", len(res.Body))
+		}
+	case AssertMaxSize:
+		if len(res.Body) > a.size {
+			return a.fail("body is %d bytes", len(res.Body))
+		}
+	}
+
+	return nil
+}
+
+// JSON returns the body decoded as json, it is decoded once for all assertions and extractors
+func (res *Response) JSON() (interface{}, error) {
+	if !res.jsonParsed {
+		res.jsonErr = json.Unmarshal(res.Body, &res.json)
+		res.jsonParsed = true
+	}
+
+	return res.json, res.jsonErr
+}
+
+func (a *Assertion) checkJSON(res *Response) error {
+	body, err := res.JSON()
+	if err != nil {
+		return a.fail("body is not json - %v", err)
+	}
+
+	actual, ok := lookupJSON(body, a.path)
+	if !ok {
+		return a.fail("%s is missing", strings.Join(a.path, "."))
+	}
+
+	if a.value != nil && !jsonEquals(actual, *a.value) {
+		data, _ := json.Marshal(actual)
+		return a.fail("%s is %s", strings.Join(a.path, "."), data)
+	}
+
+	return nil
+}
+
+func (a *Assertion) fail(format string, args ...interface{}) error {
+	return &AssertionError{Kind: a.Kind, Assertion: a.Expr, Reason: fmt.Sprintf(format, args...)}
+}
+
+// parseJSONPath parses $.a.b[0].c or a.b.0.c into the keys a, b, 0 and c
+func parseJSONPath(path string) ([]string, error) {
+	path = strings.NewReplacer("[", ".", "]", "").Replace(strings.TrimPrefix(path, "$"))
+	path = strings.TrimPrefix(path, ".")
+	if path == "" {
+		return nil, fmt.Errorf("empty json path")
+	}
+
+	return strings.Split(path, "."), nil
+}
+
+func lookupJSON(value interface{}, path []string) (interface{}, bool) {
+	for _, key := range path {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			var ok bool
+			if value, ok = v[key]; !ok {
+				return nil, false
+			}
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+
+			value = v[i]
+		default:
+			return nil, false
+		}
+	}
+
+	return value, true
+}
+
+// jsonEquals compares a json value with the expected literal, a string is compared with or without quotes
+func jsonEquals(actual interface{}, expected string) bool {
+	switch v := actual.(type) {
+	case string:
+		if unquoted, err := strconv.Unquote(expected); err == nil {
+			expected = unquoted
+		}
+
+		return v == expected
+	case float64:
+		number, err := strconv.ParseFloat(expected, 64)
+		return err == nil && number == v
+	default:
+		data, _ := json.Marshal(v)
+		return string(data) == expected
+	}
+}
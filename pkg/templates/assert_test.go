@@ -0,0 +1,78 @@
+package templates
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseJSONPath(t *testing.T) {
+	cases := []struct {
+		path string
+		keys []string
+	}{
+		{"$.data.id", []string{"data", "id"}},
+		{"data.id", []string{"data", "id"}},
+		{"$.items[0].id", []string{"items", "0", "id"}},
+		{"items.0.id", []string{"items", "0", "id"}},
+		{"$[0].id", []string{"0", "id"}},
+		{"[1]", []string{"1"}},
+		{"$.a[0][1]", []string{"a", "0", "1"}},
+	}
+
+	for _, c := range cases {
+		keys, err := parseJSONPath(c.path)
+		if err != nil {
+			t.Errorf("parseJSONPath(%q) failed - %v", c.path, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(keys, c.keys) {
+			t.Errorf("parseJSONPath(%q) = %q, want %q", c.path, keys, c.keys)
+		}
+	}
+
+	for _, path := range []string{"", "$", "$."} {
+		if _, err := parseJSONPath(path); err == nil {
+			t.Errorf("parseJSONPath(%q) should fail", path)
+		}
+	}
+}
+
+func TestJSONEquals(t *testing.T) {
+	cases := []struct {
+		actual   interface{}
+		expected string
+		equal    bool
+	}{
+		{"ok", "ok", true},
+		{"ok", `"ok"`, true},
+		{"ok", "no", false},
+		{"1", "1", true},
+		{1.0, "1", true},
+		{1.5, "1.50", true},
+		{1.0, "2", false},
+		{1.0, "one", false},
+		{true, "true", true},
+		{false, "true", false},
+		{nil, "null", true},
+		{[]interface{}{1.0, "a"}, `[1,"a"]`, true},
+		{map[string]interface{}{"a": 1.0}, `{"a":1}`, true},
+	}
+
+	for _, c := range cases {
+		if equal := jsonEquals(c.actual, c.expected); equal != c.equal {
+			t.Errorf("jsonEquals(%v, %q) = %v, want %v", c.actual, c.expected, equal, c.equal)
+		}
+	}
+}
+
+func TestAssertRootArray(t *testing.T) {
+	assertion, err := ParseAssertion("json:$[0].id=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = assertion.Check(&Response{StatusCode: 200, Body: []byte(`[{"id":1}]`)}); err != nil {
+		t.Errorf("check failed - %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+package templates
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+const (
+	ExtractJSON   = "json"
+	ExtractRegex  = "regex"
+	ExtractHeader = "header"
+	ExtractCookie = "cookie"
+)
+
+// Extractor takes a value from the response, parsed from <kind>:<argument>, see ParseExtractor
+type Extractor struct {
+	Expr string
+	Kind string
+
+	path    []string
+	pattern *regexp.Regexp
+	name    string
+}
+
+// ExtractError is returned when the value to extract is not found in the response, its class is extract-<kind>
+type ExtractError struct {
+	Kind      string
+	Extractor string
+}
+
+func (e *ExtractError) Error() string {
+	return fmt.Sprintf("extract <%s> failed - not found", e.Extractor)
+}
+
+func (e *ExtractError) ErrorClass() string {
+	return "extract-" + e.Kind
+}
+
+// ParseExtractor parses the extractors
+//   json:<path>, e.g. json:$.data.token, a non-string value is extracted as json
+//   regex:<pattern>, the first group, or the match when there is no group
+//   header:<name> and cookie:<name> set by the response
+func ParseExtractor(expr string) (*Extractor, error) {
+	segs := strings.SplitN(expr, ":", 2)
+	if len(segs) != 2 {
+		return nil, fmt.Errorf("invalid extractor <%s>, expected <kind>:<argument>", expr)
+	}
+
+	e := &Extractor{Expr: expr, Kind: segs[0]}
+
+	var err error
+	switch e.Kind {
+	case ExtractJSON:
+		e.path, err = parseJSONPath(strings.TrimSpace(segs[1]))
+	case ExtractRegex:
+		e.pattern, err = regexp.Compile(segs[1])
+	case ExtractHeader:
+		e.name = http.CanonicalHeaderKey(strings.TrimSpace(segs[1]))
+	case ExtractCookie:
+		e.name = strings.TrimSpace(segs[1])
+	default:
+		err = fmt.Errorf("unknown kind <%s>", e.Kind)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid extractor <%s> - %v", expr, err)
+	}
+
+	return e, nil
+}
+
+// Extract returns the value in res, or ExtractError when it is not found
+func (e *Extractor) Extract(res *Response) (string, error) {
+	switch e.Kind {
+	case ExtractJSON:
+		if body, err := res.JSON(); err == nil {
+			if value, ok := lookupJSON(body, e.path); ok {
+				if str, ok := value.(string); ok {
+					return str, nil
+				}
+
+				data, _ := json.Marshal(value)
+				return string(data), nil
+			}
+		}
+	case ExtractRegex:
+		if match := e.pattern.FindSubmatch(res.Body); match != nil {
+			if len(match) > 1 {
+				return string(match[1]), nil
+			}
+
+			return string(match[0]), nil
+		}
+	case ExtractHeader:
+		if values, ok := res.Header[e.name]; ok {
+			return strings.Join(values, ", "), nil
+		}
+	case ExtractCookie:
+		for _, cookie := range res.Cookies {
+			if cookie.Name == e.name {
+				return cookie.Value, nil
+			}
+		}
+	}
+
+	return "", &ExtractError{Kind: e.Kind, Extractor: e.Expr}
+}
@@ -4,26 +4,83 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ginkgoch/stress-test/pkg/client/runner"
 )
 
+// StatusError is returned when the response status code is not 2xx or 3xx
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("status code <%d> error", e.StatusCode)
+}
+
+func (e *StatusError) ErrorClass() string {
+	return fmt.Sprintf("http-%d", e.StatusCode)
+}
+
 func HttpGet(request *http.Request, client *http.Client) error {
+	return HttpCheck(request, client, nil)
+}
+
+// HttpCheck sends the request and checks the response with the assertions,
+// the status code must be 2xx or 3xx unless there is a status assertion
+func HttpCheck(request *http.Request, client *http.Client, assertions []*Assertion) error {
+	_, err := HttpDo(request, client, assertions)
+	return err
+}
+
+// HttpDo works as HttpCheck and returns the response read completely, it is nil when the request fails
+func HttpDo(request *http.Request, client *http.Client, assertions []*Assertion) (*Response, error) {
+	startTime := time.Now()
 	res, err := client.Do(request)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	defer res.Body.Close()
 
-	if res.StatusCode < 200 || res.StatusCode >= 400 {
-		return fmt.Errorf("status code <%d> error", res.StatusCode)
+	runner.AddExtra(request.Context(), "status", strconv.Itoa(res.StatusCode))
+	if !hasStatusAssertion(assertions) && (res.StatusCode < 200 || res.StatusCode >= 400) {
+		return nil, &StatusError{StatusCode: res.StatusCode}
 	}
 
-	_, err = ioutil.ReadAll(res.Body)
+	data, err := ioutil.ReadAll(res.Body)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	runner.AddExtra(request.Context(), "bytes", strconv.Itoa(len(data)))
+
+	response := &Response{
+		StatusCode: res.StatusCode,
+		Header:     res.Header,
+		Cookies:    res.Cookies(),
+		Body:       data,
+		Elapsed:    time.Since(startTime),
+	}
+
+	for _, assertion := range assertions {
+		if err = assertion.Check(response); err != nil {
+			return response, err
+		}
+	}
+
+	return response, nil
+}
+
+func hasStatusAssertion(assertions []*Assertion) bool {
+	for _, assertion := range assertions {
+		if assertion.Kind == AssertStatus {
+			return true
+		}
 	}
 
-	return nil
+	return false
 }
 
 func SendRequest(request *http.Request, client *http.Client) ([]byte, error) {
@@ -39,7 +96,7 @@ func SendRequest(request *http.Request, client *http.Client) ([]byte, error) {
 
 func ConsumeResponse(res *http.Response) ([]byte, error) {
 	if res.StatusCode < 200 || res.StatusCode >= 400 {
-		return nil, fmt.Errorf("status code <%d> error", res.StatusCode)
+		return nil, &StatusError{StatusCode: res.StatusCode}
 	}
 
 	buffer, err := ioutil.ReadAll(res.Body)
@@ -0,0 +1,135 @@
+package templates
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/ginkgoch/stress-test/pkg/client/feeder"
+	"github.com/ginkgoch/stress-test/pkg/client/runner"
+)
+
+// RequestTemplate creates the requests of the templated url, headers and body, see Text
+type RequestTemplate struct {
+	Method string
+	URL    *Text
+	// Headers are in name=value format, the value is templated
+	Headers []string
+	Body    *Text
+	// RawBody is sent as it is when Body is nil
+	RawBody     []byte
+	ContentType string
+	// Feeder feeds the records of {{.Data.name}}, it is optional
+	Feeder *feeder.Feeder
+
+	headerValues []*Text
+	seq          uint64
+}
+
+// NewRequestTemplate parses the url, the header values and the body when it is not nil
+func NewRequestTemplate(method string, url string, headers []string, body []byte, templatedBody bool) (*RequestTemplate, error) {
+	t := &RequestTemplate{Method: method, Headers: headers}
+
+	var err error
+	if t.URL, err = ParseText("url", url); err != nil {
+		return nil, fmt.Errorf("invalid url template - %v", err)
+	}
+
+	for _, header := range headers {
+		segs := strings.SplitN(header, "=", 2)
+		if len(segs) != 2 {
+			return nil, fmt.Errorf("invalid header <%s>, expected name=value", header)
+		}
+
+		value, err := ParseText(segs[0], segs[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid header template <%s> - %v", segs[0], err)
+		}
+
+		t.headerValues = append(t.headerValues, value)
+	}
+
+	if body != nil && templatedBody {
+		if t.Body, err = ParseText("body", string(body)); err != nil {
+			return nil, fmt.Errorf("invalid body template - %v", err)
+		}
+	} else {
+		t.RawBody = body
+	}
+
+	return t, nil
+}
+
+// NewRequest creates a request of the task running with ctx, the body is created for every request
+func (t *RequestTemplate) NewRequest(ctx context.Context) (*http.Request, error) {
+	vars, err := NewVars(ctx, &t.seq, t.Feeder)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.Render(ctx, vars)
+}
+
+// NewVars returns the vars of the task running with ctx, seq is increased and the next record of f is taken when f is not nil
+func NewVars(ctx context.Context, seq *uint64, f *feeder.Feeder) (*Vars, error) {
+	vars := &Vars{
+		WorkerID:  runner.WorkerID(ctx),
+		Iteration: runner.Iteration(ctx),
+		Seq:       atomic.AddUint64(seq, 1),
+	}
+
+	if f != nil {
+		record, err := f.Next(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		vars.Data = record
+	}
+
+	return vars, nil
+}
+
+// Render creates the request of the templates with vars
+func (t *RequestTemplate) Render(ctx context.Context, vars *Vars) (*http.Request, error) {
+	url, err := t.URL.Execute(vars)
+	if err != nil {
+		return nil, err
+	}
+
+	var body io.Reader
+	if t.Body != nil {
+		content, err := t.Body.Execute(vars)
+		if err != nil {
+			return nil, err
+		}
+
+		body = strings.NewReader(content)
+	} else if t.RawBody != nil {
+		body = bytes.NewReader(t.RawBody)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, t.Method, url, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.ContentType != "" {
+		request.Header.Set("Content-Type", t.ContentType)
+	}
+
+	for i, header := range t.Headers {
+		value, err := t.headerValues[i].Execute(vars)
+		if err != nil {
+			return nil, err
+		}
+
+		request.Header.Set(header[:strings.Index(header, "=")], value)
+	}
+
+	return request, nil
+}
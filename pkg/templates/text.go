@@ -0,0 +1,111 @@
+package templates
+
+import (
+	"crypto/rand"
+	"fmt"
+	mrand "math/rand"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/ginkgoch/stress-test/pkg/client/feeder"
+)
+
+// Vars is the dot of the request templates, e.g. {{.WorkerID}}, {{.Iteration}}, {{.Seq}}, {{.Data.name}} and {{.Vars.token}}
+type Vars struct {
+	// WorkerID is the 1-based id of the thread
+	WorkerID int
+	// Iteration is the 0-based iteration of the thread, or of the schedule with --rate
+	Iteration int
+	// Seq is the 1-based sequence of the request in the run
+	Seq uint64
+	// Data is the record of the feeder
+	Data feeder.Record
+	// Vars are the values extracted by the previous steps of a scenario, e.g. {{.Vars.token}}
+	Vars map[string]string
+}
+
+// Text is a template evaluated for every request, a text without {{ is returned as it is
+type Text struct {
+	raw  string
+	tmpl *template.Template
+}
+
+const randChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+var textFuncs = template.FuncMap{
+	// randInt returns a random int in [min, max]
+	"randInt": func(min int, max int) int {
+		if max <= min {
+			return min
+		}
+
+		return min + mrand.Intn(max-min+1)
+	},
+	// randString returns a random alphanumeric string of n chars
+	"randString": func(n int) string {
+		b := make([]byte, n)
+		for i := range b {
+			b[i] = randChars[mrand.Intn(len(randChars))]
+		}
+
+		return string(b)
+	},
+	"uuid": newUUID,
+	// timestamp returns the unix time in ms
+	"timestamp": func() int64 {
+		return time.Now().UnixNano() / 1e6
+	},
+	// now formats the current time with layout, e.g. {{now "2006-01-02T15:04:05Z07:00"}}
+	"now": func(layout string) string {
+		return time.Now().Format(layout)
+	},
+}
+
+func ParseText(name string, text string) (*Text, error) {
+	t := &Text{raw: text}
+	if !strings.Contains(text, "{{") {
+		return t, nil
+	}
+
+	tmpl, err := template.New(name).Funcs(textFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	t.tmpl = tmpl
+	return t, nil
+}
+
+// String returns the text of the template
+func (t *Text) String() string {
+	return t.raw
+}
+
+// IsStatic tells whether the text is the same for every request
+func (t *Text) IsStatic() bool {
+	return t.tmpl == nil
+}
+
+func (t *Text) Execute(vars *Vars) (string, error) {
+	if t.tmpl == nil {
+		return t.raw, nil
+	}
+
+	builder := new(strings.Builder)
+	if err := t.tmpl.Execute(builder, vars); err != nil {
+		return "", err
+	}
+
+	return builder.String(), nil
+}
+
+// newUUID returns a random uuid of version 4
+func newUUID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
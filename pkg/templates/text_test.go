@@ -0,0 +1,171 @@
+package templates
+
+import (
+	"context"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/ginkgoch/stress-test/pkg/client/feeder"
+	"github.com/ginkgoch/stress-test/pkg/client/runner"
+)
+
+func TestTextExecute(t *testing.T) {
+	vars := &Vars{
+		WorkerID:  3,
+		Iteration: 7,
+		Seq:       42,
+		Data:      feeder.Record{"name": "amy"},
+		Vars:      map[string]string{"token": "t1"},
+	}
+
+	cases := []struct {
+		text     string
+		expected string
+	}{
+		{"/users", "/users"},
+		{"/users/{{.WorkerID}}/{{.Iteration}}", "/users/3/7"},
+		{"seq={{.Seq}}", "seq=42"},
+		{"{{.Data.name}}:{{.Vars.token}}", "amy:t1"},
+		{"{{randInt 5 5}}", "5"},
+		{"{{randInt 9 1}}", "9"},
+		{"{{len (randString 12)}}", "12"},
+		{`{{now "2006"}}`, ""},
+	}
+
+	for _, c := range cases {
+		text, err := ParseText("test", c.text)
+		if err != nil {
+			t.Errorf("ParseText(%q) failed - %v", c.text, err)
+			continue
+		}
+
+		actual, err := text.Execute(vars)
+		if err != nil {
+			t.Errorf("Execute(%q) failed - %v", c.text, err)
+			continue
+		}
+
+		if c.expected != "" && actual != c.expected {
+			t.Errorf("Execute(%q) = %q, want %q", c.text, actual, c.expected)
+		}
+
+		if text.String() != c.text {
+			t.Errorf("String() = %q, want %q", text.String(), c.text)
+		}
+	}
+}
+
+func TestTextFuncs(t *testing.T) {
+	for text, pattern := range map[string]string{
+		"{{uuid}}":         `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`,
+		"{{randInt 1 3}}":  `^[1-3]$`,
+		"{{randString 8}}": `^[a-zA-Z0-9]{8}$`,
+		"{{timestamp}}":    `^\d{13}$`,
+	} {
+		tmpl, err := ParseText("test", text)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i := 0; i < 20; i++ {
+			actual, err := tmpl.Execute(&Vars{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !regexp.MustCompile(pattern).MatchString(actual) {
+				t.Errorf("%s = %q does not match %s", text, actual, pattern)
+				break
+			}
+		}
+	}
+}
+
+func TestTextErrors(t *testing.T) {
+	if _, err := ParseText("test", "{{.WorkerID"); err == nil {
+		t.Error("ParseText of an unclosed action should fail")
+	}
+
+	if _, err := ParseText("test", "{{unknown}}"); err == nil {
+		t.Error("ParseText of an unknown function should fail")
+	}
+
+	text, err := ParseText("test", "{{.Data.missing}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = text.Execute(&Vars{Data: feeder.Record{}}); err == nil {
+		t.Error("Execute of a missing key should fail")
+	}
+
+	if !mustParse(t, "/static").IsStatic() || mustParse(t, "/{{.Seq}}").IsStatic() {
+		t.Error("only the text without {{ is static")
+	}
+}
+
+func mustParse(t *testing.T, text string) *Text {
+	tmpl, err := ParseText("test", text)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tmpl
+}
+
+func TestRequestTemplate(t *testing.T) {
+	records := []feeder.Record{{"id": "a"}, {"id": "b"}}
+	f, err := feeder.NewFeeder(records, feeder.OrderSequential, feeder.PolicyRecycle, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl, err := NewRequestTemplate("POST", "http://localhost/users/{{.Data.id}}", []string{"X-Seq={{.Seq}}", "X-Static=1=2"}, []byte(`{"worker":{{.WorkerID}}}`), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl.Feeder = f
+	tmpl.ContentType = "application/json"
+
+	ctx := runner.WithWorker(context.Background(), 2)
+	for i, id := range []string{"a", "b", "a"} {
+		request, err := tmpl.NewRequest(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		body, _ := ioutil.ReadAll(request.Body)
+		if request.URL.Path != "/users/"+id || string(body) != `{"worker":2}` {
+			t.Errorf("request %d is %s with %s", i, request.URL, body)
+		}
+
+		if seq := request.Header.Get("X-Seq"); seq != strconv.Itoa(i+1) {
+			t.Errorf("request %d X-Seq is %s", i, seq)
+		}
+
+		if request.Header.Get("X-Static") != "1=2" || request.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("request %d headers are %v", i, request.Header)
+		}
+	}
+
+	raw, err := NewRequestTemplate("POST", "http://localhost/", nil, []byte("{{raw}}"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request, err := raw.NewRequest(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if body, _ := ioutil.ReadAll(request.Body); string(body) != "{{raw}}" {
+		t.Errorf("raw body is %s", body)
+	}
+
+	if _, err = NewRequestTemplate("GET", "http://localhost/", []string{"no-value"}, nil, false); err == nil {
+		t.Error("a header without = should fail")
+	}
+}